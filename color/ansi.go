@@ -0,0 +1,86 @@
+package color
+
+import (
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// ANSI SEQUENCE ANALYSIS
+// =============================================================================
+
+// Normalize rewrites the SGR sequences in s into a canonical form, so that
+// strings which render identically compare equal. Consecutive sequences are
+// merged, redundant resets and no-op sequences are dropped, and each change
+// of style is emitted as a single sequence with parameters in a fixed order
+// (e.g. "\033[1m\033[31m" and "\033[31;1m" both become "\033[1;31m").
+func Normalize(s string) string {
+	var out strings.Builder
+	var current, emitted Style
+
+	last := 0
+	for _, loc := range ansiRegex.FindAllStringIndex(s, -1) {
+		writeNormalized(&out, s[last:loc[0]], current, &emitted)
+		current.apply(s[loc[0]+2 : loc[1]-1])
+		last = loc[1]
+	}
+	writeNormalized(&out, s[last:], current, &emitted)
+
+	// Keep a trailing reset if the input ended with the style cleared
+	if current == (Style{}) && emitted != (Style{}) {
+		out.WriteString(escape("0"))
+	}
+
+	return out.String()
+}
+
+// writeNormalized writes text, preceded by a sequence switching from the
+// emitted style to the current one if they differ
+func writeNormalized(out *strings.Builder, text string, current Style, emitted *Style) {
+	if text == "" {
+		return
+	}
+
+	if current != *emitted {
+		switch {
+		case current == (Style{}):
+			out.WriteString(escape("0"))
+		case emitted.Attrs&^current.Attrs != 0 ||
+			(emitted.Fg.Kind != ColorDefault && current.Fg.Kind == ColorDefault) ||
+			(emitted.Bg.Kind != ColorDefault && current.Bg.Kind == ColorDefault):
+			// Something was switched off, so start from a clean slate
+			out.WriteString(escape("0;" + current.params()))
+		default:
+			out.WriteString(escape(current.params()))
+		}
+		*emitted = current
+	}
+
+	out.WriteString(text)
+}
+
+// EqualVisible reports whether a and b render identically, ignoring
+// differences in how their SGR sequences are written
+func EqualVisible(a, b string) bool {
+	return Normalize(a) == Normalize(b)
+}
+
+// csiRegex matches any CSI escape sequence, not just SGR
+var csiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// CountANSI returns the number of ANSI escape sequences in s
+func CountANSI(s string) int {
+	return len(csiRegex.FindAllStringIndex(s, -1))
+}
+
+// IsBalanced reports whether every style set by an SGR sequence is switched
+// off again before the end of s, either by a reset ("\033[0m" or "\033[m")
+// or by the matching closing code (such as 22 for bold or 39 for the
+// foreground color), so the style can't bleed into whatever is printed next
+func IsBalanced(s string) bool {
+	var current Style
+	for _, seq := range ansiRegex.FindAllString(s, -1) {
+		current.apply(seq[2 : len(seq)-1])
+	}
+	return current == (Style{})
+}
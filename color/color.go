@@ -1,17 +1,29 @@
 package color
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 func escape(code string) string {
+	if terminfoEnabled() {
+		return terminfoEscape(code)
+	}
 	return fmt.Sprintf("\033[%sm", code)
 }
 
 func wrap(code, text string) string {
+	if GetColorLevel() == ColorLevelNone {
+		return text
+	}
 	return fmt.Sprintf("%s%s%s", escape(code), text, escape("0"))
 }
 
@@ -84,24 +96,103 @@ func Reverse(text string) string       { return wrap("7", text) }
 func Hidden(text string) string        { return wrap("8", text) }
 func Strikethrough(text string) string { return wrap("9", text) }
 
+// The following attributes are less widely supported, so they are only
+// applied when the terminal is believed to render them and otherwise return
+// the text unchanged. Overline is supported by most modern emulators (VTE
+// based terminals, kitty, WezTerm, foot, Konsole, iTerm2 and mintty), while
+// framed and encircled are rarely implemented outside of mintty.
+
+// Overline draws a line above the text (SGR 53)
+func Overline(text string) string { return wrapIfSupported(AttrOverline, "53", text) }
+
+// Framed draws a frame around the text (SGR 51)
+func Framed(text string) string { return wrapIfSupported(AttrFramed, "51", text) }
+
+// Encircled draws a circle around the text (SGR 52)
+func Encircled(text string) string { return wrapIfSupported(AttrEncircled, "52", text) }
+
+// wrapIfSupported wraps text with code only if the terminal supports attr
+func wrapIfSupported(attr Attribute, code, text string) string {
+	if !supportsAttribute(attr) {
+		return text
+	}
+	return wrap(code, text)
+}
+
+// supportsAttribute makes a best guess, from the environment, at whether the
+// terminal renders one of the less common attributes
+func supportsAttribute(attr Attribute) bool {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	mintty := program == "mintty" || strings.Contains(term, "mintty")
+
+	if term == "" || term == "dumb" {
+		return false
+	}
+
+	switch attr {
+	case AttrItalic, AttrStrikethrough:
+		// The Linux console substitutes colors for these
+		return term != "linux"
+	case AttrBlink:
+		return !strings.Contains(term, "kitty")
+	case AttrOverline:
+		return mintty ||
+			os.Getenv("VTE_VERSION") != "" ||
+			os.Getenv("KONSOLE_VERSION") != "" ||
+			strings.Contains(term, "kitty") ||
+			strings.Contains(term, "foot") ||
+			program == "WezTerm" ||
+			program == "iTerm.app"
+	case AttrFramed, AttrEncircled:
+		return mintty
+	default:
+		return true
+	}
+}
+
+var (
+	// attributeDetector reports whether the terminal supports an attribute,
+	// and can be replaced to simulate other terminals
+	attributeDetector = supportsAttribute
+
+	supportedAttributes     []string
+	supportedAttributesOnce sync.Once
+)
+
+// SupportedAttributes returns the names of the text attributes the terminal
+// is believed to support (as used in style specs, e.g. "italic"), judged
+// from TERM and related environment variables. The result is computed once
+// and cached.
+func SupportedAttributes() []string {
+	supportedAttributesOnce.Do(func() {
+		for _, a := range attributes {
+			if attributeDetector(a.attr) {
+				supportedAttributes = append(supportedAttributes, a.name)
+			}
+		}
+	})
+	return append([]string(nil), supportedAttributes...)
+}
+
 // =============================================================================
 // COMBINED FORMATTING (as shown in transcription)
 // =============================================================================
 
 func BoldRed(text string) string {
-	return fmt.Sprintf("\033[1;31m%s\033[0m", text)
+	return wrap("1;31", text)
 }
 
 func BoldGreen(text string) string {
-	return fmt.Sprintf("\033[1;32m%s\033[0m", text)
+	return wrap("1;32", text)
 }
 
 func BoldYellow(text string) string {
-	return fmt.Sprintf("\033[1;33m%s\033[0m", text)
+	return wrap("1;33", text)
 }
 
 func BoldBlue(text string) string {
-	return fmt.Sprintf("\033[1;34m%s\033[0m", text)
+	return wrap("1;34", text)
 }
 
 // =============================================================================
@@ -118,36 +209,75 @@ func supports256Color() bool {
 
 // Color256 sets foreground color using 256-color palette
 func Color256(colorNumber int, text string) string {
-	if !supports256Color() {
+	if GetColorLevel() < ColorLevel256 {
 		// Fallback to nearest standard color
 		return fallbackColor(colorNumber, text)
 	}
-	return fmt.Sprintf("\033[38;5;%dm%s\033[0m", colorNumber, text)
+	return wrapEscape(fmt.Sprintf("38;5;%d", colorNumber), text)
 }
 
 // Background256 sets background color using 256-color palette
 func Background256(colorNumber int, text string) string {
-	if !supports256Color() {
+	if GetColorLevel() < ColorLevel256 {
 		return text // Fallback to no background
 	}
-	return fmt.Sprintf("\033[48;5;%dm%s\033[0m", colorNumber, text)
+	return wrapEscape(fmt.Sprintf("48;5;%d", colorNumber), text)
+}
+
+// FgBg sets a foreground and background together from SGR codes (e.g.
+// "31" and "47"), emitting a single sequence and a single reset
+func FgBg(fgCode, bgCode string, text string) string {
+	switch {
+	case fgCode == "":
+		return wrap(bgCode, text)
+	case bgCode == "":
+		return wrap(fgCode, text)
+	}
+	return wrap(fgCode+";"+bgCode, text)
+}
+
+// FgBg256 sets a foreground and background from the 256-color palette in a
+// single sequence, falling back to the nearest standard colors
+func FgBg256(fg, bg int, text string) string {
+	if fg < 0 || fg > 255 || bg < 0 || bg > 255 {
+		return text // Invalid palette index
+	}
+	return Style{Fg: Indexed(fg), Bg: Indexed(bg)}.Render(text)
+}
+
+// FgBgRGB sets a 24-bit foreground and background in a single sequence,
+// falling back to the 256-color palette or standard colors as needed
+func FgBgRGB(fr, fg, fb, br, bg, bb int, text string) string {
+	for _, v := range []int{fr, fg, fb, br, bg, bb} {
+		if v < 0 || v > 255 {
+			return text // Invalid RGB values
+		}
+	}
+	return Style{Fg: RGBColor(fr, fg, fb), Bg: RGBColor(br, bg, bb)}.Render(text)
 }
 
 // fallbackColor maps 256 colors to nearest standard color
 func fallbackColor(colorNumber int, text string) string {
+	basic := nearestBasic(colorNumber)
+	if basic < 0 {
+		return text // Default
+	}
+	return wrap(Named(basic).params(false), text)
+}
+
+// nearestBasic maps a 256-color palette index to the nearest of the 16
+// standard colors, or -1 if none is a reasonable match
+func nearestBasic(colorNumber int) int {
 	switch {
-	case colorNumber < 8:
-		// Standard colors 0-7
-		return wrap(fmt.Sprintf("%d", 30+colorNumber), text)
 	case colorNumber < 16:
-		// Bright colors 8-15
-		return wrap(fmt.Sprintf("%d", 82+colorNumber), text)
+		// Standard colors 0-7 and bright colors 8-15
+		return colorNumber
 	case colorNumber >= 232:
 		// Grayscale - use white or black
 		if colorNumber > 243 {
-			return White(text)
+			return 7
 		}
-		return Black(text)
+		return 0
 	default:
 		// Color cube - rough approximation
 		r := (colorNumber - 16) / 36
@@ -156,26 +286,236 @@ func fallbackColor(colorNumber int, text string) string {
 
 		// Convert to nearest standard color
 		if r > g && r > b {
-			return Red(text)
+			return 1
 		} else if g > r && g > b {
-			return Green(text)
+			return 2
 		} else if b > r && b > g {
-			return Blue(text)
+			return 4
 		}
-		return text // Default
+		return -1
+	}
+}
+
+// Palette256RGB is the canonical RGB value of each 256-color palette index:
+// the 16 system colors (as in xterm's defaults, which terminals may
+// override), the 6x6x6 color cube and the 24-step grayscale ramp
+var Palette256RGB = buildPalette256()
+
+// cubeSteps are the component values of the color cube coordinates 0-5
+var cubeSteps = [6]int{0, 95, 135, 175, 215, 255}
+
+// buildPalette256 computes Palette256RGB
+func buildPalette256() [256][3]int {
+	palette := [256][3]int{
+		{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+		{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+		{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+
+	for i := range 216 {
+		palette[16+i] = [3]int{cubeSteps[i/36], cubeSteps[i/6%6], cubeSteps[i%6]}
 	}
+	for i := range 24 {
+		v := 8 + 10*i
+		palette[232+i] = [3]int{v, v, v}
+	}
+
+	return palette
+}
+
+// rgbToPalette converts RGB values (0-255) to the nearest color cube index
+func rgbToPalette(r, g, b int) int {
+	// Formula: 16 + (36 * r/255 * 5) + (6 * g/255 * 5) + (b/255 * 5)
+	return 16 + (36 * (r * 5 / 255)) + (6 * (g * 5 / 255)) + (b * 5 / 255)
 }
 
 // RGB converts RGB values to 256-color palette
 func RGB(r, g, b int, text string) string {
 	// Convert RGB (0-255) to 256-color palette
-	// Formula: 16 + (36 * r/255 * 5) + (6 * g/255 * 5) + (b/255 * 5)
 	if r < 0 || r > 255 || g < 0 || g > 255 || b < 0 || b > 255 {
 		return text // Invalid RGB values
 	}
 
-	colorNumber := 16 + (36 * (r * 5 / 255)) + (6 * (g * 5 / 255)) + (b * 5 / 255)
-	return Color256(colorNumber, text)
+	return Color256(rgbToPalette(r, g, b), text)
+}
+
+// CubeColor sets a foreground color from the 6x6x6 color cube, where r, g
+// and b are cube coordinates from 0 to 5 (palette index 16 + 36r + 6g + b)
+func CubeColor(r, g, b int, text string) string {
+	if r < 0 || r > 5 || g < 0 || g > 5 || b < 0 || b > 5 {
+		return text // Invalid cube coordinates
+	}
+
+	return Color256(16+36*r+6*g+b, text)
+}
+
+// Best sets a foreground color using the highest-fidelity representation
+// for the current color level (see SetColorLevel): 24-bit truecolor, else
+// the nearest 256-palette color, else the nearest of the 16 standard colors,
+// else no color at all
+func Best(r, g, b int, text string) string {
+	if r < 0 || r > 255 || g < 0 || g > 255 || b < 0 || b > 255 {
+		return text // Invalid RGB values
+	}
+
+	switch GetColorLevel() {
+	case ColorLevelTrueColor:
+		return wrap(fmt.Sprintf("38;2;%d;%d;%d", r, g, b), text)
+	case ColorLevel256:
+		return wrap(Indexed(nearestPalette(r, g, b, 16, 256)).params(false), text)
+	case ColorLevelBasic:
+		return wrap(Named(nearestPalette(r, g, b, 0, 16)).params(false), text)
+	default:
+		return text
+	}
+}
+
+// nearestPalette returns the palette index in [from, to) whose canonical
+// RGB value is closest to r, g, b
+func nearestPalette(r, g, b, from, to int) int {
+	best, bestDist := from, math.MaxInt
+	for i := from; i < to; i++ {
+		c := Palette256RGB[i]
+		dr, dg, db := c[0]-r, c[1]-g, c[2]-b
+		if dist := dr*dr + dg*dg + db*db; dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// Gray sets a grayscale foreground color from the 24-step ramp (palette
+// 232-255), where level 0 is the darkest and 23 the lightest. Levels are
+// clamped to that range. Without 256-color support the darker half falls
+// back to bright black and the lighter half to dim.
+func Gray(level int, text string) string {
+	level = max(0, min(23, level))
+
+	if GetColorLevel() < ColorLevel256 {
+		if level < 12 {
+			return BrightBlack(text)
+		}
+		return Dim(text)
+	}
+	return Color256(232+level, text)
+}
+
+// GrayPercent sets a grayscale foreground color where p ranges from 0.0
+// (darkest) to 1.0 (lightest)
+func GrayPercent(p float64, text string) string {
+	return Gray(int(math.Round(p*23)), text)
+}
+
+// =============================================================================
+// GRADIENTS
+// =============================================================================
+
+// Mix blends two RGB colors, returning a at t=0 and b at t=1. t is clamped
+// to that range.
+func Mix(a, b [3]int, t float64) [3]int {
+	t = math.Max(0, math.Min(1, t))
+
+	var c [3]int
+	for i := range c {
+		c[i] = a[i] + int(math.Round(float64(b[i]-a[i])*t))
+	}
+	return c
+}
+
+// Lighten blends c towards white by amt (0 = unchanged, 1 = white)
+func Lighten(c [3]int, amt float64) [3]int {
+	return Mix(c, [3]int{255, 255, 255}, amt)
+}
+
+// Darken blends c towards black by amt (0 = unchanged, 1 = black)
+func Darken(c [3]int, amt float64) [3]int {
+	return Mix(c, [3]int{0, 0, 0}, amt)
+}
+
+// PaletteGradient returns n colorizers evenly interpolated between the start
+// and end RGB colors, for coloring a legend or a series of bars. Colors use
+// truecolor where available and otherwise the nearest supported palette
+// color (see Best). When n is 1 only the start color is returned.
+func PaletteGradient(n int, start, end [3]int) []func(string) string {
+	if n <= 0 {
+		return nil
+	}
+
+	colorizers := make([]func(string) string, n)
+	for i := range n {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+
+		c := Mix(start, end, t)
+		colorizers[i] = func(text string) string {
+			return Best(c[0], c[1], c[2], text)
+		}
+	}
+	return colorizers
+}
+
+// =============================================================================
+// CONTRAST
+// =============================================================================
+
+// Luminance returns the WCAG relative luminance of an RGB color, from 0 for
+// black to 1 for white
+func Luminance(c [3]int) float64 {
+	channel := func(v int) float64 {
+		x := math.Max(0, math.Min(255, float64(v))) / 255
+		if x <= 0.04045 {
+			return x / 12.92
+		}
+		return math.Pow((x+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c[0]) + 0.7152*channel(c[1]) + 0.0722*channel(c[2])
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1
+// (no contrast) to 21 (black on white). The order of fg and bg doesn't
+// matter.
+func ContrastRatio(fg, bg [3]int) float64 {
+	l1, l2 := Luminance(fg), Luminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// MeetsAA reports whether fg on bg meets WCAG AA for normal text (4.5:1)
+func MeetsAA(fg, bg [3]int) bool {
+	return ContrastRatio(fg, bg) >= 4.5
+}
+
+// MeetsAAA reports whether fg on bg meets WCAG AAA for normal text (7:1)
+func MeetsAAA(fg, bg [3]int) bool {
+	return ContrastRatio(fg, bg) >= 7
+}
+
+// =============================================================================
+// IDENTIFIER COLORS
+// =============================================================================
+
+// idColors are 256-color palette entries for ForID, picked to be distinct
+// from each other and to keep at least 3:1 contrast against both black and
+// white backgrounds
+var idColors = []int{27, 28, 31, 33, 64, 97, 99, 129, 130, 133, 134, 160, 162, 164, 166, 168}
+
+// ForID returns a color function chosen by hashing id, so the same
+// identifier (a hostname, username or pod) always gets the same color,
+// across runs as well as within one. Useful for telling sources apart in
+// interleaved logs.
+func ForID(id string) func(string) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	code := idColors[h.Sum32()%uint32(len(idColors))]
+
+	return func(text string) string {
+		return Color256(code, text)
+	}
 }
 
 // =============================================================================
@@ -228,13 +568,91 @@ func isTerminal() bool {
 
 // SafeColor applies color only if terminal supports it
 func SafeColor(colorFunc func(string) string, text string) string {
-	info := DetectTerminalCapabilities()
+	return SafeColorWith(DetectTerminalCapabilities(), colorFunc, text)
+}
+
+// SafeColorWith applies color only if info says the terminal supports it,
+// for callers that have already detected capabilities or want to force them
+func SafeColorWith(info TerminalInfo, colorFunc func(string) string, text string) string {
 	if info.SupportsColor {
 		return colorFunc(text)
 	}
 	return text
 }
 
+// =============================================================================
+// COLOR LEVELS
+// =============================================================================
+
+// ColorLevel is the maximum color depth used when rendering
+type ColorLevel int
+
+const (
+	ColorLevelNone      ColorLevel = iota // No escape sequences at all
+	ColorLevelBasic                       // The 16 standard colors
+	ColorLevel256                         // The 256-color palette
+	ColorLevelTrueColor                   // 24-bit RGB
+)
+
+var (
+	colorLevel      ColorLevel
+	colorLevelSet   bool
+	colorLevelLock  sync.RWMutex
+	detectLevelOnce sync.Once
+	detectedLevel   ColorLevel
+)
+
+// SetColorLevel overrides the detected color level. Colors deeper than the
+// level are quantized down to the nearest color it supports, and
+// ColorLevelNone disables styling entirely. This is an escape hatch for
+// terminals whose capabilities are detected incorrectly.
+func SetColorLevel(level ColorLevel) {
+	colorLevelLock.Lock()
+	defer colorLevelLock.Unlock()
+	colorLevel = level
+	colorLevelSet = true
+}
+
+// ResetColorLevel removes any override set with SetColorLevel
+func ResetColorLevel() {
+	colorLevelLock.Lock()
+	defer colorLevelLock.Unlock()
+	colorLevelSet = false
+}
+
+// GetColorLevel returns the color level in effect, either the override set
+// with SetColorLevel or the level detected from the environment
+func GetColorLevel() ColorLevel {
+	colorLevelLock.RLock()
+	level, set := colorLevel, colorLevelSet
+	colorLevelLock.RUnlock()
+
+	if set {
+		return level
+	}
+	return DetectColorLevel()
+}
+
+// DetectColorLevel returns the color level the terminal is believed to
+// support. Detection runs once and the result is cached.
+func DetectColorLevel() ColorLevel {
+	detectLevelOnce.Do(func() {
+		info := DetectTerminalCapabilities()
+		switch {
+		case info.SupportsTrueColor:
+			detectedLevel = ColorLevelTrueColor
+		case supports256Color():
+			detectedLevel = ColorLevel256
+		default:
+			detectedLevel = ColorLevelBasic
+		}
+	})
+
+	colorLevelLock.RLock()
+	defer colorLevelLock.RUnlock()
+	return detectedLevel
+}
+
 // =============================================================================
 // THEMES (as suggested in homework)
 // =============================================================================
@@ -244,6 +662,10 @@ type Theme struct {
 	Warning func(string) string
 	Success func(string) string
 	Info    func(string) string
+
+	// Selection marks selected text, e.g. the current item in a list
+	// (nil = black on light gray, or reverse video on basic terminals)
+	Selection func(string) string
 }
 
 var (
@@ -276,31 +698,160 @@ var (
 	}
 )
 
-var currentTheme = DefaultTheme
+var (
+	currentTheme = DefaultTheme
+	themeLock    sync.RWMutex
+)
 
 // SetTheme sets the global theme
 func SetTheme(theme Theme) {
+	themeLock.Lock()
+	defer themeLock.Unlock()
 	currentTheme = theme
 }
 
+// getTheme returns the global theme
+func getTheme() Theme {
+	themeLock.RLock()
+	defer themeLock.RUnlock()
+	return currentTheme
+}
+
 // Error prints error message using current theme
 func Error(text string) string {
-	return currentTheme.Error(text)
+	return getTheme().Error(text)
 }
 
 // Warning prints warning message using current theme
 func Warning(text string) string {
-	return currentTheme.Warning(text)
+	return getTheme().Warning(text)
 }
 
 // Success prints success message using current theme
 func Success(text string) string {
-	return currentTheme.Success(text)
+	return getTheme().Success(text)
 }
 
 // Info prints info message using current theme
 func Info(text string) string {
-	return currentTheme.Info(text)
+	return getTheme().Info(text)
+}
+
+// Selection marks text as selected using the current theme's Selection
+// style. By default the colors are set explicitly (black on light gray), so
+// the result is readable regardless of the terminal's own colors, falling
+// back to reverse video on terminals without 256-color support.
+func Selection(text string) string {
+	if selection := getTheme().Selection; selection != nil {
+		return selection(text)
+	}
+	if GetColorLevel() < ColorLevel256 {
+		return Reverse(text)
+	}
+	return wrap("38;5;16;48;5;252", text)
+}
+
+// ApplyNamed applies the current theme's function with the given name
+// ("error", "warning", "success", "info" or "selection", case-insensitive)
+// to text, so configuration can refer to theme styles by name
+func ApplyNamed(name, text string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "error":
+		return Error(text), nil
+	case "warning":
+		return Warning(text), nil
+	case "success":
+		return Success(text), nil
+	case "info":
+		return Info(text), nil
+	case "selection":
+		return Selection(text), nil
+	default:
+		return text, fmt.Errorf("color: unknown theme style %q", name)
+	}
+}
+
+// LevelThresholds sets the lowest numeric level that ThemeForLevel maps to
+// each theme color. Levels below Info are left uncolored.
+type LevelThresholds struct {
+	Error   int
+	Warning int
+	Info    int
+}
+
+// SlogThresholds matches the levels used by log/slog
+var SlogThresholds = LevelThresholds{Error: 8, Warning: 4, Info: 0}
+
+var (
+	levelThresholds     = SlogThresholds
+	levelThresholdsLock sync.RWMutex
+)
+
+// SetLevelThresholds sets the thresholds used by ThemeForLevel
+func SetLevelThresholds(thresholds LevelThresholds) {
+	levelThresholdsLock.Lock()
+	defer levelThresholdsLock.Unlock()
+	levelThresholds = thresholds
+}
+
+// ThemeForLevel returns the current theme's color function for a numeric
+// log level, such as a slog.Level converted to int. Higher levels are more
+// severe; by default the thresholds follow slog (see SetLevelThresholds).
+func ThemeForLevel(level int) func(string) string {
+	levelThresholdsLock.RLock()
+	thresholds := levelThresholds
+	levelThresholdsLock.RUnlock()
+
+	theme := getTheme()
+	switch {
+	case level >= thresholds.Error:
+		return theme.Error
+	case level >= thresholds.Warning:
+		return theme.Warning
+	case level >= thresholds.Info:
+		return theme.Info
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+// themeKey is the context key for a per-request theme
+type themeKey struct{}
+
+// WithTheme returns a copy of ctx carrying theme, so that concurrent
+// requests or goroutines can each use their own theme without touching the
+// global one
+func WithTheme(ctx context.Context, theme Theme) context.Context {
+	return context.WithValue(ctx, themeKey{}, theme)
+}
+
+// ThemeFromContext returns the theme carried by ctx, falling back to the
+// global theme if there is none
+func ThemeFromContext(ctx context.Context) Theme {
+	if theme, ok := ctx.Value(themeKey{}).(Theme); ok {
+		return theme
+	}
+	return getTheme()
+}
+
+// ErrorCtx formats an error message using the theme from ctx
+func ErrorCtx(ctx context.Context, text string) string {
+	return ThemeFromContext(ctx).Error(text)
+}
+
+// WarningCtx formats a warning message using the theme from ctx
+func WarningCtx(ctx context.Context, text string) string {
+	return ThemeFromContext(ctx).Warning(text)
+}
+
+// SuccessCtx formats a success message using the theme from ctx
+func SuccessCtx(ctx context.Context, text string) string {
+	return ThemeFromContext(ctx).Success(text)
+}
+
+// InfoCtx formats an info message using the theme from ctx
+func InfoCtx(ctx context.Context, text string) string {
+	return ThemeFromContext(ctx).Info(text)
 }
 
 // =============================================================================
@@ -320,6 +871,116 @@ func VisualLength(str string) int {
 	return len(StripANSI(str))
 }
 
+// RuneWidth returns the number of terminal columns r occupies: two for wide
+// characters (CJK, most emoji), none for combining marks and variation
+// selectors, and one otherwise
+func RuneWidth(r rune) int {
+	switch {
+	case r >= 0x0300 && r <= 0x036F, // Combining diacritical marks
+		r >= 0x200B && r <= 0x200F, // Zero-width spaces and joiners
+		r >= 0xFE00 && r <= 0xFE0F: // Variation selectors
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0xA4CF, // CJK
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFF00 && r <= 0xFF60, // Fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // Emoji and pictographs
+		r >= 0x1F680 && r <= 0x1F6FF, // Transport and map symbols
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions
+		return 2
+	default:
+		return 1
+	}
+}
+
+// DisplayWidth returns the number of terminal columns str occupies, ignoring
+// ANSI codes and measuring each rune with RuneWidth
+func DisplayWidth(str string) int {
+	width := 0
+	for _, r := range StripANSI(str) {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// Highlight applies colorFunc to the visible runes in the range [start, end),
+// leaving the rest of the string untouched. Indices count visible runes only,
+// so any ANSI sequences already present in s are skipped over. Since
+// colorFunc ends with a reset, the style in effect at end is restored after
+// the range so the rest of s keeps its styling.
+func Highlight(s string, start, end int, colorFunc func(string) string) string {
+	if start < 0 {
+		start = 0
+	}
+	if end <= start {
+		return s
+	}
+
+	startByte, endByte := -1, -1
+	runeIndex, runeEnd := 0, 0
+	var current Style
+
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if loc := ansiRegex.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				current.apply(s[i+2 : i+loc[1]-1])
+				i += loc[1]
+				continue
+			}
+		}
+
+		if runeIndex == start {
+			startByte = i
+		}
+		if runeIndex == end {
+			endByte = i
+			break
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		runeEnd = i
+		runeIndex++
+	}
+
+	if startByte < 0 {
+		return s // Range starts beyond the visible text
+	}
+	if endByte < 0 {
+		// The range runs to the end, so leave trailing sequences outside it
+		return s[:startByte] + colorFunc(s[startByte:runeEnd]) + s[runeEnd:]
+	}
+
+	restore := ""
+	if current != (Style{}) && GetColorLevel() != ColorLevelNone {
+		restore = escape(current.params())
+	}
+	return s[:startByte] + colorFunc(s[startByte:endByte]) + restore + s[endByte:]
+}
+
+// ColorLines applies colorFunc to each line of s separately, so every line
+// opens and resets its own style. This keeps multi-line output colored when
+// piped through pagers such as less, which reset styling at line breaks.
+// Empty lines are left as they are.
+func ColorLines(colorFunc func(string) string, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// Keep the carriage return of CRLF endings outside the style
+		body, cr := strings.CutSuffix(line, "\r")
+		if body == "" {
+			continue
+		}
+		lines[i] = colorFunc(body)
+		if cr {
+			lines[i] += "\r"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ShowColorPalette displays all 256 colors in a grid format
 func ShowColorPalette() {
 	fmt.Println("=== 256 Color Palette ===")
@@ -353,6 +1014,32 @@ func ShowColorPalette() {
 	fmt.Println()
 }
 
+// ShowAttributes displays a sample of every text attribute along with
+// whether the current terminal is believed to support it. When NO_COLOR is
+// set only the plain labels are printed.
+func ShowAttributes() {
+	fmt.Println("=== Text Attributes ===")
+
+	info := DetectTerminalCapabilities()
+	disabled := isColorDisabled()
+
+	for _, a := range attributes {
+		label := fmt.Sprintf("%-14s", a.name)
+		if disabled {
+			fmt.Println(label)
+			continue
+		}
+
+		supported := info.SupportsColor && supportsAttribute(a.attr)
+		note := "supported"
+		if !supported {
+			note = "not supported"
+		}
+
+		fmt.Printf("%s %s  (%s)\n", label, wrap(a.code, "The quick brown fox"), note)
+	}
+}
+
 // =============================================================================
 // LOG LEVEL IMPLEMENTATION (from transcription examples)
 // =============================================================================
@@ -398,6 +1085,36 @@ func ColoredProgressBar(progress float64, width int) string {
 	return fmt.Sprintf("%s %3d%%", bar.String(), percentage)
 }
 
+// ColoredProgressBarFunc is like ColoredProgressBar, but each filled
+// character is colored by the colorizer fillColor returns for its index
+// (0 to width-1), allowing gradients or threshold coloring, e.g. indexing
+// into PaletteGradient(width, start, end). A nil fillColor or colorizer
+// leaves the character uncolored.
+func ColoredProgressBarFunc(progress float64, width int, fillColor func(int) func(string) string) string {
+	progress = math.Max(0, math.Min(1, progress))
+	filled := int(progress * float64(width))
+	empty := width - filled
+
+	var bar strings.Builder
+
+	for i := range filled {
+		var colorFunc func(string) string
+		if fillColor != nil {
+			colorFunc = fillColor(i)
+		}
+		if colorFunc == nil {
+			bar.WriteString("█")
+			continue
+		}
+		bar.WriteString(colorFunc("█"))
+	}
+
+	bar.WriteString(Cyan(strings.Repeat("░", empty)))
+
+	percentage := int(progress * 100)
+	return fmt.Sprintf("%s %3d%%", bar.String(), percentage)
+}
+
 // ShowStatus displays a status indicator with color
 func ShowStatus(name string, success bool) string {
 	status := "✗"
@@ -420,6 +1137,33 @@ func isColorDisabled() bool {
 	return os.Getenv("NO_COLOR") != ""
 }
 
+// ConditionalColorWith applies color only if NO_COLOR is unset and info
+// says the terminal supports color, instead of checking stdout
+func ConditionalColorWith(info TerminalInfo, colorFunc func(string) string, text string) string {
+	if isColorDisabled() || !info.SupportsColor {
+		return text
+	}
+	return colorFunc(text)
+}
+
+// EnabledFor reports whether color should be written to w: NO_COLOR must be
+// unset and w must be a terminal
+func EnabledFor(w io.Writer) bool {
+	if isColorDisabled() {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+}
+
 // ConditionalColor applies color only if not disabled by environment
 func ConditionalColor(colorFunc func(string) string, text string) string {
 	if isColorDisabled() || !isTerminal() {
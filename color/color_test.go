@@ -0,0 +1,278 @@
+package color
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestHighlightRestoresStyle(t *testing.T) {
+	SetColorLevel(ColorLevelBasic)
+	defer ResetColorLevel()
+
+	got := Highlight(Red("abcdef"), 1, 3, Bold)
+
+	runes := Decompose(got)
+	if len(runes) != 6 {
+		t.Fatalf("Highlight(...) has %d visible runes, want 6: %q", len(runes), got)
+	}
+	for _, r := range runes[3:] {
+		if r.Style.Fg != Named(1) {
+			t.Errorf("rune %q after the range has foreground %v, want red: %q", r.Rune, r.Style.Fg, got)
+		}
+	}
+	if StripANSI(got) != "abcdef" {
+		t.Errorf("StripANSI(Highlight(...)) = %q, want %q", StripANSI(got), "abcdef")
+	}
+}
+
+func TestHighlightPastEnd(t *testing.T) {
+	SetColorLevel(ColorLevelBasic)
+	defer ResetColorLevel()
+
+	var inner string
+	got := Highlight(Red("abc"), 1, 10, func(text string) string {
+		inner = text
+		return Bold(text)
+	})
+
+	if inner != "bc" {
+		t.Errorf("colorFunc got %q, want %q", inner, "bc")
+	}
+	if StripANSI(got) != "abc" {
+		t.Errorf("StripANSI(Highlight(...)) = %q, want %q", StripANSI(got), "abc")
+	}
+}
+
+func TestPalette256RGB(t *testing.T) {
+	tests := []struct {
+		index int
+		want  [3]int
+	}{
+		{16, [3]int{0, 0, 0}},
+		{196, [3]int{255, 0, 0}},
+		{231, [3]int{255, 255, 255}},
+		{232, [3]int{8, 8, 8}},
+		{244, [3]int{128, 128, 128}},
+		{255, [3]int{238, 238, 238}},
+	}
+
+	for _, tt := range tests {
+		if got := Palette256RGB[tt.index]; got != tt.want {
+			t.Errorf("Palette256RGB[%d] = %v, want %v", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestStripANSIKeepLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ST terminated", "click \033]8;;http://x.com\033\\here\033]8;;\033\\", "click here"},
+		{"BEL terminated", "\033]8;id=1;http://x.com\ahere\033]8;;\a!", "here!"},
+		{"colored label", "\033]8;;http://x.com\033\\\033[31mhere\033[0m\033]8;;\033\\", "here"},
+		{"unclosed", "see \033]8;;http://x.com\033\\docs", "see docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripANSIKeepLinks(tt.in); got != tt.want {
+				t.Errorf("StripANSIKeepLinks(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBalanced(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"plain", true},
+		{"\033[31mx\033[0m", true},
+		{"\033[31mx\033[m", true},
+		{"\033[1mx\033[22m", true},
+		{"\033[3;4mx\033[23;24m", true},
+		{"\033[7mx\033[27m", true},
+		{"\033[31;41mx\033[39;49m", true},
+		{"\033[38;5;0mx\033[39m", true},
+		{"\033[31mx", false},
+		{"\033[1;4mx\033[22m", false},
+		{"\033[0mx\033[31m", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsBalanced(tt.in); got != tt.want {
+			t.Errorf("IsBalanced(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"abc", 3},
+		{"日本", 4},
+		{"\033[31m日本\033[0m", 4},
+		{"é", 1},
+		{"🚀", 2},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.in); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStyledString(t *testing.T) {
+	SetColorLevel(ColorLevelTrueColor)
+	defer ResetColorLevel()
+
+	var s StyledString
+	s.Append("a", Style{Attrs: AttrBold, Fg: Named(1)}).
+		Append("b", Style{Attrs: AttrBold, Fg: Named(1), Bg: Named(4)}).
+		Append("c", Style{Fg: Named(2)}).
+		Append("d", Style{})
+
+	want := "\033[1;31ma\033[44mb\033[0;32mc\033[39md"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestStyledStringClearsQuantizedColor(t *testing.T) {
+	SetColorLevel(ColorLevelBasic)
+	defer ResetColorLevel()
+
+	// Palette index 16 has no basic equivalent, so the red must be cleared
+	// rather than carried over
+	var s StyledString
+	s.Append("a", Style{Fg: Named(1), Bg: Named(4)}).
+		Append("b", Style{Fg: Indexed(16), Bg: Indexed(16)})
+
+	want := "\033[31;44ma\033[39;49mb\033[0m"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestStyledStringNoColor(t *testing.T) {
+	SetColorLevel(ColorLevelNone)
+	defer ResetColorLevel()
+
+	var s StyledString
+	s.Append("a", Style{Attrs: AttrBold}).Append("b", Style{Fg: Named(1)})
+	if got := s.String(); got != "ab" {
+		t.Errorf("String() = %q, want %q", got, "ab")
+	}
+}
+
+func TestStyleRoundTrip(t *testing.T) {
+	styles := []Style{
+		{},
+		{Attrs: AttrBold | AttrUnderline},
+		{Fg: Named(1)},
+		{Fg: Named(15), Bg: Named(0)},
+		{Attrs: AttrItalic, Fg: Indexed(196), Bg: Indexed(0)},
+		{Fg: RGBColor(0x12, 0x34, 0x56), Bg: RGBColor(255, 255, 255)},
+	}
+
+	for _, want := range styles {
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%#v) error: %v", want, err)
+		}
+		got, err := ParseStyle(string(text))
+		if err != nil {
+			t.Fatalf("ParseStyle(%q) error: %v", text, err)
+		}
+		if got != want {
+			t.Errorf("ParseStyle(%q) = %#v, want %#v", text, got, want)
+		}
+	}
+}
+
+func TestStyleMarshalInvalidColor(t *testing.T) {
+	styles := []Style{
+		{Fg: Named(16)},
+		{Fg: Named(-1)},
+		{Bg: Indexed(256)},
+		{Fg: RGBColor(256, 0, 0)},
+	}
+
+	for _, s := range styles {
+		if _, err := s.MarshalText(); err == nil {
+			t.Errorf("MarshalText(%#v) succeeded, want an error", s)
+		}
+		_ = s.String() // must not panic
+	}
+}
+
+// failWriter fails every write until fail is cleared
+type failWriter struct {
+	out  bytes.Buffer
+	fail bool
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("write failed")
+	}
+	return w.out.Write(p)
+}
+
+func bracket(s string) string { return "[" + s + "]" }
+
+func TestHighlighter(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	var out bytes.Buffer
+	h := NewHighlighter(&out, []Rule{
+		{regexp.MustCompile(`ERROR`), bracket},
+		{regexp.MustCompile(`ERR\w*`), func(s string) string { return "<" + s + ">" }},
+		{regexp.MustCompile(`\d+`), func(s string) string { return "<" + s + ">" }},
+	})
+
+	h.Write([]byte("ERROR at li"))
+	if out.Len() != 0 {
+		t.Fatalf("partial line written early: %q", out.String())
+	}
+	h.Write([]byte("ne 42\nERRNO 7"))
+	if want := "[ERROR] at line <42>\n"; out.String() != want {
+		t.Errorf("after full line got %q, want %q", out.String(), want)
+	}
+
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[ERROR] at line <42>\n<ERRNO> <7>"; out.String() != want {
+		t.Errorf("after Flush got %q, want %q", out.String(), want)
+	}
+}
+
+func TestHighlighterRetryAfterError(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	w := &failWriter{fail: true}
+	h := NewHighlighter(w, nil)
+
+	h.Write([]byte("start "))
+	if n, err := h.Write([]byte("line\n")); err == nil || n != 0 {
+		t.Fatalf("Write = %d, %v, want 0 and an error", n, err)
+	}
+
+	w.fail = false
+	if n, err := h.Write([]byte("line\n")); err != nil || n != 5 {
+		t.Fatalf("retry Write = %d, %v, want 5 and no error", n, err)
+	}
+	if want := "start line\n"; w.out.String() != want {
+		t.Errorf("got %q, want %q", w.out.String(), want)
+	}
+}
@@ -0,0 +1,140 @@
+package color
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// STREAM HIGHLIGHTING
+// =============================================================================
+
+// Rule colors every match of Pattern using Color
+type Rule struct {
+	Pattern *regexp.Regexp
+	Color   func(string) string
+}
+
+// Highlighter is an io.Writer that colorizes text matching a set of rules
+// before passing it on, like grc or ccze for log tailing. Input is buffered
+// until a full line is available, so matches are never split across writes.
+type Highlighter struct {
+	w     io.Writer
+	rules []Rule
+	buf   []byte
+	lock  sync.Mutex
+}
+
+// NewHighlighter creates a Highlighter writing to w. When rules produce
+// overlapping matches, the earlier rule wins. Highlighting is disabled when
+// NO_COLOR is set.
+func NewHighlighter(w io.Writer, rules []Rule) *Highlighter {
+	return &Highlighter{
+		w:     w,
+		rules: rules,
+	}
+}
+
+// Write buffers p and writes out every complete line it contains. If the
+// underlying write fails, p is not kept, so the caller can retry it without
+// the bytes being buffered twice.
+func (h *Highlighter) Write(p []byte) (int, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	buffered := len(h.buf)
+	h.buf = append(h.buf, p...)
+
+	end := bytes.LastIndexByte(h.buf, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	lines := h.buf[:end+1]
+	if _, err := io.WriteString(h.w, h.highlight(string(lines))); err != nil {
+		h.buf = h.buf[:buffered]
+		return 0, err
+	}
+
+	h.buf = append(h.buf[:0], h.buf[end+1:]...)
+	return len(p), nil
+}
+
+// Flush writes out any buffered partial line
+func (h *Highlighter) Flush() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.buf) == 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(h.w, h.highlight(string(h.buf)))
+	h.buf = h.buf[:0]
+	return err
+}
+
+// highlight applies the rules to each line of text
+func (h *Highlighter) highlight(text string) string {
+	if isColorDisabled() {
+		return text
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		body, newline := strings.CutSuffix(line, "\n")
+		lines[i] = h.highlightLine(body)
+		if newline {
+			lines[i] += "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// highlightLine applies the rules to a single line, coloring non-overlapping
+// matches
+func (h *Highlighter) highlightLine(text string) string {
+	type match struct {
+		start, end int
+		color      func(string) string
+	}
+
+	var matches []match
+	for _, rule := range h.rules {
+		for _, loc := range rule.Pattern.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue // Nothing to color
+			}
+
+			overlaps := false
+			for _, m := range matches {
+				if loc[0] < m.end && m.start < loc[1] {
+					overlaps = true
+					break
+				}
+			}
+			if !overlaps {
+				matches = append(matches, match{loc[0], loc[1], rule.Color})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		out.WriteString(text[last:m.start])
+		out.WriteString(m.color(text[m.start:m.end]))
+		last = m.end
+	}
+	out.WriteString(text[last:])
+
+	return out.String()
+}
@@ -0,0 +1,42 @@
+package color
+
+import (
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// TERMINAL PROBING
+// =============================================================================
+
+var (
+	probeOnce   sync.Once
+	probeResult bool
+)
+
+// ProbeTrueColor asks the terminal directly whether it supports truecolor,
+// which catches terminals that don't advertise it through COLORTERM. It sets
+// an unusual RGB color, queries the active style with a DECRQSS request and
+// checks whether the color is echoed back, waiting at most timeout for a
+// reply. If stdin or stdout isn't a terminal, or no reply arrives in time,
+// the environment heuristics are used instead.
+//
+// The probe puts the terminal into raw mode briefly, so it should be called
+// once at startup before reading any input. The result is cached, and when
+// the probe confirms truecolor the detected color level is raised to match.
+func ProbeTrueColor(timeout time.Duration) bool {
+	probeOnce.Do(func() {
+		supported, ok := queryTrueColor(timeout)
+		if !ok {
+			supported = DetectTerminalCapabilities().SupportsTrueColor
+		}
+		probeResult = supported
+
+		if supported && DetectColorLevel() < ColorLevelTrueColor {
+			colorLevelLock.Lock()
+			detectedLevel = ColorLevelTrueColor
+			colorLevelLock.Unlock()
+		}
+	})
+	return probeResult
+}
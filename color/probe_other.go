@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package color
+
+import "time"
+
+// queryTrueColor is unsupported on this platform, so the environment
+// heuristics are always used
+func queryTrueColor(timeout time.Duration) (supported, ok bool) {
+	return false, false
+}
@@ -0,0 +1,73 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package color
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// queryTrueColor performs the DECRQSS probe. ok is false if the terminal
+// couldn't be queried or didn't reply in time.
+func queryTrueColor(timeout time.Duration) (supported, ok bool) {
+	in := int(os.Stdin.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return false, false
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return false, false
+	}
+	defer term.Restore(in, state)
+
+	// Set a distinctive color, request the current SGR state, then reset
+	fmt.Fprint(os.Stdout, "\033[38;2;1;2;3m\033P$qm\033\\\033[0m")
+
+	reply, ok := readReply(in, timeout)
+	if !ok {
+		return false, false
+	}
+
+	// Terminals reply with either colon or semicolon separated parameters
+	return strings.Contains(reply, "2:1:2:3") || strings.Contains(reply, "2;1;2;3"), true
+}
+
+// readReply reads a DCS reply terminated by ST (ESC \) from fd, giving up
+// once timeout has elapsed
+func readReply(fd int, timeout time.Duration) (string, bool) {
+	deadline := time.Now().Add(timeout)
+	var reply []byte
+	buf := make([]byte, 64)
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return "", false
+		}
+
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n == 0 {
+			return "", false
+		}
+
+		n, err = unix.Read(fd, buf)
+		if err != nil || n == 0 {
+			return "", false
+		}
+		reply = append(reply, buf[:n]...)
+
+		if i := strings.Index(string(reply), "\033P"); i >= 0 && strings.Contains(string(reply[i:]), "\033\\") {
+			return string(reply[i:]), true
+		}
+	}
+}
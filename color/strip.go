@@ -0,0 +1,154 @@
+package color
+
+import (
+	"io"
+	"strings"
+)
+
+// =============================================================================
+// STREAM STRIPPING
+// =============================================================================
+
+// stripState tracks where a stripper is within an escape sequence
+type stripState int
+
+const (
+	stateText   stripState = iota // Plain text
+	stateEscape                   // After ESC
+	stateCSI                      // Inside a CSI sequence (ESC [)
+	stateOSC                      // Inside an OSC sequence (ESC ])
+	stateOSCEsc                   // After ESC inside an OSC sequence
+)
+
+// strippingReader removes ANSI escape sequences from an underlying reader
+type strippingReader struct {
+	r     io.Reader
+	state stripState
+}
+
+// NewStrippingReader returns a reader that removes ANSI escape sequences
+// (CSI sequences such as colors and cursor movement, OSC sequences such as
+// titles and hyperlinks, and two-byte escapes) from r as it is read. The
+// position within a sequence is carried between reads, so sequences split
+// across Read calls are still removed completely.
+func NewStrippingReader(r io.Reader) io.Reader {
+	return &strippingReader{r: r}
+}
+
+// Read reads from the underlying reader and strips escape sequences in place
+func (sr *strippingReader) Read(p []byte) (int, error) {
+	for {
+		n, err := sr.r.Read(p)
+		n = sr.strip(p[:n])
+
+		// Don't report an empty read just because a chunk was all escapes
+		if n > 0 || err != nil || len(p) == 0 {
+			return n, err
+		}
+	}
+}
+
+// strip removes escape sequence bytes from p in place, returning the number
+// of bytes kept
+func (sr *strippingReader) strip(p []byte) int {
+	kept := 0
+
+	for _, c := range p {
+		switch sr.state {
+		case stateText:
+			if c == 0x1b {
+				sr.state = stateEscape
+				continue
+			}
+			p[kept] = c
+			kept++
+
+		case stateEscape:
+			switch c {
+			case '[':
+				sr.state = stateCSI
+			case ']':
+				sr.state = stateOSC
+			default:
+				sr.state = stateText // Two-byte escape
+			}
+
+		case stateCSI:
+			// Parameter and intermediate bytes continue until a final byte
+			if c >= 0x40 && c <= 0x7e {
+				sr.state = stateText
+			}
+
+		case stateOSC:
+			// Terminated by BEL or ST (ESC \)
+			if c == 0x07 {
+				sr.state = stateText
+			} else if c == 0x1b {
+				sr.state = stateOSCEsc
+			}
+
+		case stateOSCEsc:
+			if c == '\\' {
+				sr.state = stateText
+			} else {
+				sr.state = stateOSC
+			}
+		}
+	}
+
+	return kept
+}
+
+// StripANSIKeepLinks removes escape sequences from s like StripANSI, also
+// covering cursor movement and OSC sequences, while keeping the visible
+// labels of OSC 8 hyperlinks. A link is opened by an OSC 8 sequence with a
+// URI and closed by one with an empty URI; both are removed and the label
+// between them is kept as plain text. A link left open at the end of s
+// keeps its label too.
+func StripANSIKeepLinks(s string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(s); {
+		if s[i] != 0x1b {
+			out.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		if i+1 < len(s) && s[i+1] == ']' {
+			i = oscEnd(s, i+2)
+			continue
+		}
+
+		i = escapeEnd(s, i)
+	}
+
+	return out.String()
+}
+
+// oscEnd returns the index just past the BEL or ST terminator of the OSC
+// sequence whose body starts at s[start] (just after "ESC ]")
+func oscEnd(s string, start int) int {
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == 0x07:
+			return i + 1
+		case s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\':
+			return i + 2
+		}
+	}
+	return len(s)
+}
+
+// escapeEnd returns the index just past the CSI or two-byte escape sequence
+// starting at s[start]
+func escapeEnd(s string, start int) int {
+	i := start + 1
+	if i < len(s) && s[i] == '[' {
+		i++
+		for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+			i++
+		}
+	}
+	return min(i+1, len(s))
+}
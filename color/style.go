@@ -0,0 +1,417 @@
+package color
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// STYLE VALUES
+// =============================================================================
+
+// Attribute is a set of text attributes, combined with bitwise OR
+type Attribute uint16
+
+const (
+	AttrBold Attribute = 1 << iota
+	AttrDim
+	AttrItalic
+	AttrUnderline
+	AttrBlink
+	AttrReverse
+	AttrHidden
+	AttrStrikethrough
+	AttrOverline
+	AttrFramed
+	AttrEncircled
+)
+
+// attributes lists each attribute with its spec name and SGR code, in the
+// order they are emitted
+var attributes = []struct {
+	attr Attribute
+	name string
+	code string
+}{
+	{AttrBold, "bold", "1"},
+	{AttrDim, "dim", "2"},
+	{AttrItalic, "italic", "3"},
+	{AttrUnderline, "underline", "4"},
+	{AttrBlink, "blink", "5"},
+	{AttrReverse, "reverse", "7"},
+	{AttrHidden, "hidden", "8"},
+	{AttrStrikethrough, "strikethrough", "9"},
+	{AttrFramed, "framed", "51"},
+	{AttrEncircled, "encircled", "52"},
+	{AttrOverline, "overline", "53"},
+}
+
+// ColorKind identifies how a Color is specified
+type ColorKind int
+
+const (
+	ColorDefault ColorKind = iota // Terminal default (no color set)
+	ColorNamed                    // One of the 16 standard colors
+	ColorIndexed                  // A 256-color palette index
+	ColorRGB                      // A 24-bit RGB value
+)
+
+// Color is a foreground or background color used by a Style
+type Color struct {
+	Kind    ColorKind
+	Index   int // Palette index for ColorNamed (0-15) and ColorIndexed (0-255)
+	R, G, B int // Components for ColorRGB (0-255)
+}
+
+// colorNames maps the 16 standard colors to their palette index
+var colorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"brightblack", "brightred", "brightgreen", "brightyellow",
+	"brightblue", "brightmagenta", "brightcyan", "brightwhite",
+}
+
+// Named returns one of the 16 standard colors (0-7 normal, 8-15 bright)
+func Named(index int) Color {
+	return Color{Kind: ColorNamed, Index: index}
+}
+
+// Indexed returns a 256-color palette color
+func Indexed(index int) Color {
+	return Color{Kind: ColorIndexed, Index: index}
+}
+
+// RGBColor returns a 24-bit color
+func RGBColor(r, g, b int) Color {
+	return Color{Kind: ColorRGB, R: r, G: g, B: b}
+}
+
+// sgr returns the SGR parameters selecting this color, or "" for the
+// default, quantizing the color down to the current color level
+func (c Color) sgr(background bool) string {
+	level := GetColorLevel()
+
+	if c.Kind == ColorRGB && level < ColorLevelTrueColor {
+		c = Indexed(rgbToPalette(c.R, c.G, c.B))
+	}
+	if c.Kind == ColorIndexed && level < ColorLevel256 {
+		basic := nearestBasic(c.Index)
+		if basic < 0 {
+			return ""
+		}
+		c = Named(basic)
+	}
+	return c.params(background)
+}
+
+// params returns the SGR parameters selecting this color exactly as specified
+func (c Color) params(background bool) string {
+	base, extended := 30, "38"
+	if background {
+		base, extended = 40, "48"
+	}
+
+	switch c.Kind {
+	case ColorNamed:
+		if c.Index < 8 {
+			return strconv.Itoa(base + c.Index)
+		}
+		return strconv.Itoa(base + 60 + c.Index - 8)
+	case ColorIndexed:
+		return fmt.Sprintf("%s;5;%d", extended, c.Index)
+	case ColorRGB:
+		return fmt.Sprintf("%s;2;%d;%d;%d", extended, c.R, c.G, c.B)
+	default:
+		return ""
+	}
+}
+
+// String returns the spec form of the color ("red", "196" or "#ff0000").
+// A named color outside 0-15 has no spec form and is shown as "named(N)".
+func (c Color) String() string {
+	switch c.Kind {
+	case ColorNamed:
+		if c.Index < 0 || c.Index >= len(colorNames) {
+			return fmt.Sprintf("named(%d)", c.Index)
+		}
+		return colorNames[c.Index]
+	case ColorIndexed:
+		return strconv.Itoa(c.Index)
+	case ColorRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	default:
+		return ""
+	}
+}
+
+// parseColor parses a named color, a palette index or a hex value
+func parseColor(value string) (Color, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return Color{}, fmt.Errorf("invalid hex color %q", value)
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid hex color %q", value)
+		}
+		return RGBColor(int(rgb>>16&0xff), int(rgb>>8&0xff), int(rgb&0xff)), nil
+	}
+
+	if index, err := strconv.Atoi(value); err == nil {
+		if index < 0 || index > 255 {
+			return Color{}, fmt.Errorf("color index %d out of range 0-255", index)
+		}
+		return Indexed(index), nil
+	}
+
+	name := strings.ReplaceAll(value, "-", "")
+	for i, n := range colorNames {
+		if n == name {
+			return Named(i), nil
+		}
+	}
+
+	return Color{}, fmt.Errorf("unknown color %q", value)
+}
+
+// Style is a combination of text attributes and colors that can be parsed
+// from and serialized to a spec such as "bold;fg=red;bg=#001122". It
+// implements encoding.TextMarshaler and encoding.TextUnmarshaler so it can
+// be used directly in JSON or YAML configuration.
+type Style struct {
+	Attrs Attribute
+	Fg    Color
+	Bg    Color
+}
+
+// ParseStyle parses a style spec. Spec entries are separated by semicolons
+// and are either attribute names (bold, dim, italic, underline, blink,
+// reverse, hidden, strikethrough, framed, encircled, overline) or fg=/bg=
+// colors, where a color is a name
+// ("red", "bright-blue"), a 256-color index ("196") or a hex value ("#f00").
+func ParseStyle(spec string) (Style, error) {
+	var style Style
+
+	for _, token := range strings.Split(spec, ";") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		if key, value, ok := strings.Cut(token, "="); ok {
+			c, err := parseColor(value)
+			if err != nil {
+				return Style{}, fmt.Errorf("color: invalid style %q: %w", spec, err)
+			}
+
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "fg":
+				style.Fg = c
+			case "bg":
+				style.Bg = c
+			default:
+				return Style{}, fmt.Errorf("color: invalid style %q: unknown key %q", spec, key)
+			}
+			continue
+		}
+
+		found := false
+		for _, a := range attributes {
+			if strings.EqualFold(token, a.name) {
+				style.Attrs |= a.attr
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Style{}, fmt.Errorf("color: invalid style %q: unknown attribute %q", spec, token)
+		}
+	}
+
+	return style, nil
+}
+
+// String returns the canonical spec for the style
+func (s Style) String() string {
+	var parts []string
+	for _, a := range attributes {
+		if s.Attrs&a.attr != 0 {
+			parts = append(parts, a.name)
+		}
+	}
+	if s.Fg.Kind != ColorDefault {
+		parts = append(parts, "fg="+s.Fg.String())
+	}
+	if s.Bg.Kind != ColorDefault {
+		parts = append(parts, "bg="+s.Bg.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+// valid reports whether the color is within range for its kind, so that its
+// spec form parses back to the same color
+func (c Color) valid() bool {
+	switch c.Kind {
+	case ColorNamed:
+		return c.Index >= 0 && c.Index < len(colorNames)
+	case ColorIndexed:
+		return c.Index >= 0 && c.Index <= 255
+	case ColorRGB:
+		return c.R >= 0 && c.R <= 255 && c.G >= 0 && c.G <= 255 && c.B >= 0 && c.B <= 255
+	default:
+		return true
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler. It returns an error for a
+// color outside its range, which has no spec that ParseStyle would accept.
+func (s Style) MarshalText() ([]byte, error) {
+	if !s.Fg.valid() {
+		return nil, fmt.Errorf("color: invalid foreground color %s", s.Fg)
+	}
+	if !s.Bg.valid() {
+		return nil, fmt.Errorf("color: invalid background color %s", s.Bg)
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Style) UnmarshalText(text []byte) error {
+	style, err := ParseStyle(string(text))
+	if err != nil {
+		return err
+	}
+	*s = style
+	return nil
+}
+
+// sgr returns the combined SGR parameters for the style
+func (s Style) sgr() string {
+	return s.join(Color.sgr)
+}
+
+// params returns the combined SGR parameters for the style, with colors
+// exactly as specified
+func (s Style) params() string {
+	return s.join(Color.params)
+}
+
+// join combines attribute codes with the color parameters from colorParams
+func (s Style) join(colorParams func(Color, bool) string) string {
+	var codes []string
+	for _, a := range attributes {
+		if s.Attrs&a.attr != 0 {
+			codes = append(codes, a.code)
+		}
+	}
+	if fg := colorParams(s.Fg, false); fg != "" {
+		codes = append(codes, fg)
+	}
+	if bg := colorParams(s.Bg, true); bg != "" {
+		codes = append(codes, bg)
+	}
+	return strings.Join(codes, ";")
+}
+
+// apply updates the style with the SGR parameters from an escape sequence
+// (the part between "\033[" and "m"). Unrecognized parameters are ignored.
+func (s *Style) apply(params string) {
+	if params == "" {
+		*s = Style{}
+		return
+	}
+
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		code, err := strconv.Atoi(fields[i])
+		if fields[i] == "" {
+			code, err = 0, nil
+		}
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case code == 0:
+			*s = Style{}
+		case (code >= 1 && code <= 9) || (code >= 51 && code <= 53):
+			for _, a := range attributes {
+				if a.code == strconv.Itoa(code) {
+					s.Attrs |= a.attr
+				}
+			}
+		case code == 54:
+			s.Attrs &^= AttrFramed | AttrEncircled
+		case code == 55:
+			s.Attrs &^= AttrOverline
+		case code == 22:
+			s.Attrs &^= AttrBold | AttrDim
+		case code == 23:
+			s.Attrs &^= AttrItalic
+		case code == 24:
+			s.Attrs &^= AttrUnderline
+		case code == 25:
+			s.Attrs &^= AttrBlink
+		case code == 27:
+			s.Attrs &^= AttrReverse
+		case code == 28:
+			s.Attrs &^= AttrHidden
+		case code == 29:
+			s.Attrs &^= AttrStrikethrough
+		case code >= 30 && code <= 37:
+			s.Fg = Named(code - 30)
+		case code >= 90 && code <= 97:
+			s.Fg = Named(code - 90 + 8)
+		case code == 39:
+			s.Fg = Color{}
+		case code >= 40 && code <= 47:
+			s.Bg = Named(code - 40)
+		case code >= 100 && code <= 107:
+			s.Bg = Named(code - 100 + 8)
+		case code == 49:
+			s.Bg = Color{}
+		case code == 38 || code == 48:
+			c, consumed := parseExtendedColor(fields[i+1:])
+			i += consumed
+			if code == 38 {
+				s.Fg = c
+			} else {
+				s.Bg = c
+			}
+		}
+	}
+}
+
+// parseExtendedColor parses the parameters following a 38 or 48 code,
+// returning the color and the number of parameters consumed
+func parseExtendedColor(fields []string) (Color, int) {
+	values := make([]int, 0, 4)
+	for _, f := range fields {
+		v, _ := strconv.Atoi(f)
+		values = append(values, v)
+	}
+
+	switch {
+	case len(values) >= 2 && values[0] == 5:
+		return Indexed(values[1]), 2
+	case len(values) >= 4 && values[0] == 2:
+		return RGBColor(values[1], values[2], values[3]), 4
+	default:
+		return Color{}, len(values)
+	}
+}
+
+// Render applies the style to text
+func (s Style) Render(text string) string {
+	code := s.sgr()
+	if code == "" {
+		return text
+	}
+	return wrap(code, text)
+}
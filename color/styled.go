@@ -0,0 +1,142 @@
+package color
+
+import (
+	"strings"
+)
+
+// =============================================================================
+// STYLED STRINGS
+// =============================================================================
+
+// styledRun is a piece of text drawn in a single style
+type styledRun struct {
+	text  string
+	style Style
+}
+
+// StyledString builds a line from runs of styled text. Unlike wrapping each
+// segment in its own color function, String emits only the attributes that
+// change between runs and a single reset at the end, which keeps the output
+// small. The zero value is an empty string ready to use.
+type StyledString struct {
+	runs []styledRun
+}
+
+// Append adds text drawn in style, returning the string for chaining
+func (s *StyledString) Append(text string, style Style) *StyledString {
+	if text == "" {
+		return s
+	}
+
+	// Merge with the previous run when the style is unchanged
+	if n := len(s.runs); n > 0 && s.runs[n-1].style == style {
+		s.runs[n-1].text += text
+		return s
+	}
+
+	s.runs = append(s.runs, styledRun{text, style})
+	return s
+}
+
+// String returns the text with minimal ANSI escape sequences
+func (s *StyledString) String() string {
+	if GetColorLevel() == ColorLevelNone {
+		return s.plain()
+	}
+
+	var out strings.Builder
+	var current Style
+
+	for _, run := range s.runs {
+		if params := transition(current, run.style); params != "" {
+			out.WriteString(escape(params))
+		}
+		out.WriteString(run.text)
+		current = run.style
+	}
+
+	if current != (Style{}) {
+		out.WriteString(escape("0"))
+	}
+
+	return out.String()
+}
+
+// plain returns the text without any styling
+func (s *StyledString) plain() string {
+	var out strings.Builder
+	for _, run := range s.runs {
+		out.WriteString(run.text)
+	}
+	return out.String()
+}
+
+// transition returns the SGR parameters that change the terminal from one
+// style to the next, or "" if nothing needs to change. Removing an attribute
+// falls back to a reset, since the off codes don't map one-to-one (22 clears
+// both bold and dim). Colors are compared as emitted at the current color
+// level, so one that quantizes away is cleared rather than left showing the
+// previous color.
+func transition(from, to Style) string {
+	if from.Attrs&^to.Attrs != 0 {
+		if params := to.sgr(); params != "" {
+			return "0;" + params
+		}
+		return "0"
+	}
+
+	var codes []string
+	for _, a := range attributes {
+		if to.Attrs&a.attr != 0 && from.Attrs&a.attr == 0 {
+			codes = append(codes, a.code)
+		}
+	}
+
+	if fg := to.Fg.sgr(false); fg != from.Fg.sgr(false) {
+		if fg == "" {
+			fg = "39"
+		}
+		codes = append(codes, fg)
+	}
+	if bg := to.Bg.sgr(true); bg != from.Bg.sgr(true) {
+		if bg == "" {
+			bg = "49"
+		}
+		codes = append(codes, bg)
+	}
+
+	return strings.Join(codes, ";")
+}
+
+// StyledRune is a visible rune along with the style active where it appears
+type StyledRune struct {
+	Rune  rune
+	Style Style
+}
+
+// Decompose splits s into its visible runes, each carrying the attributes
+// and colors set by the SGR sequences before it. Resets and attribute
+// changes accumulate as a terminal would apply them. Other escape sequences,
+// such as cursor movement, are dropped.
+func Decompose(s string) []StyledRune {
+	var runes []StyledRune
+	var current Style
+
+	add := func(text string) {
+		for _, r := range text {
+			runes = append(runes, StyledRune{r, current})
+		}
+	}
+
+	last := 0
+	for _, loc := range csiRegex.FindAllStringIndex(s, -1) {
+		add(s[last:loc[0]])
+		if s[loc[1]-1] == 'm' {
+			current.apply(s[loc[0]+2 : loc[1]-1])
+		}
+		last = loc[1]
+	}
+	add(s[last:])
+
+	return runes
+}
@@ -0,0 +1,113 @@
+package color
+
+import "text/template"
+
+// =============================================================================
+// RAW ESCAPE SEQUENCES
+// =============================================================================
+
+// Raw SGR sequences for embedding in templates and format strings. Unlike
+// the color functions these are always emitted, so check color support
+// before using them directly (or use ConditionalTemplateFuncMap).
+const (
+	Reset = "\033[0m"
+
+	FgBlack   = "\033[30m"
+	FgRed     = "\033[31m"
+	FgGreen   = "\033[32m"
+	FgYellow  = "\033[33m"
+	FgBlue    = "\033[34m"
+	FgMagenta = "\033[35m"
+	FgCyan    = "\033[36m"
+	FgWhite   = "\033[37m"
+
+	FgBrightBlack   = "\033[90m"
+	FgBrightRed     = "\033[91m"
+	FgBrightGreen   = "\033[92m"
+	FgBrightYellow  = "\033[93m"
+	FgBrightBlue    = "\033[94m"
+	FgBrightMagenta = "\033[95m"
+	FgBrightCyan    = "\033[96m"
+	FgBrightWhite   = "\033[97m"
+
+	BgBlack   = "\033[40m"
+	BgRed     = "\033[41m"
+	BgGreen   = "\033[42m"
+	BgYellow  = "\033[43m"
+	BgBlue    = "\033[44m"
+	BgMagenta = "\033[45m"
+	BgCyan    = "\033[46m"
+	BgWhite   = "\033[47m"
+
+	BoldSeq          = "\033[1m"
+	DimSeq           = "\033[2m"
+	ItalicSeq        = "\033[3m"
+	UnderlineSeq     = "\033[4m"
+	ReverseSeq       = "\033[7m"
+	StrikethroughSeq = "\033[9m"
+)
+
+// templateSequences maps template function names to their sequences
+var templateSequences = map[string]string{
+	"reset": Reset,
+
+	"black":   FgBlack,
+	"red":     FgRed,
+	"green":   FgGreen,
+	"yellow":  FgYellow,
+	"blue":    FgBlue,
+	"magenta": FgMagenta,
+	"cyan":    FgCyan,
+	"white":   FgWhite,
+
+	"brightBlack":   FgBrightBlack,
+	"brightRed":     FgBrightRed,
+	"brightGreen":   FgBrightGreen,
+	"brightYellow":  FgBrightYellow,
+	"brightBlue":    FgBrightBlue,
+	"brightMagenta": FgBrightMagenta,
+	"brightCyan":    FgBrightCyan,
+	"brightWhite":   FgBrightWhite,
+
+	"blackBg":   BgBlack,
+	"redBg":     BgRed,
+	"greenBg":   BgGreen,
+	"yellowBg":  BgYellow,
+	"blueBg":    BgBlue,
+	"magentaBg": BgMagenta,
+	"cyanBg":    BgCyan,
+	"whiteBg":   BgWhite,
+
+	"bold":          BoldSeq,
+	"dim":           DimSeq,
+	"italic":        ItalicSeq,
+	"underline":     UnderlineSeq,
+	"reverse":       ReverseSeq,
+	"strikethrough": StrikethroughSeq,
+}
+
+// TemplateFuncMap returns functions for template.Funcs that emit the raw
+// sequences above, named after the color functions, e.g.
+// {{red}}error{{reset}} or {{bold}}{{greenBg}}ok{{reset}}
+func TemplateFuncMap() template.FuncMap {
+	return templateFuncMap(true)
+}
+
+// ConditionalTemplateFuncMap is like TemplateFuncMap, but every function
+// returns "" if NO_COLOR is set or stdout isn't a terminal when the map is
+// built
+func ConditionalTemplateFuncMap() template.FuncMap {
+	return templateFuncMap(!isColorDisabled() && isTerminal())
+}
+
+// templateFuncMap builds the function map, emitting nothing unless enabled
+func templateFuncMap(enabled bool) template.FuncMap {
+	funcs := make(template.FuncMap, len(templateSequences))
+	for name, seq := range templateSequences {
+		if !enabled {
+			seq = ""
+		}
+		funcs[name] = func() string { return seq }
+	}
+	return funcs
+}
@@ -0,0 +1,125 @@
+package color
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// =============================================================================
+// TERMINFO
+// =============================================================================
+
+var (
+	useTerminfo   bool
+	terminfoCache = map[string]string{}
+	terminfoLock  sync.RWMutex
+)
+
+// SetUseTerminfo makes escape sequences come from the terminal's terminfo
+// entry (the setaf, setab, bold and sgr0 capabilities, looked up with tput)
+// instead of being hardcoded ANSI, for terminals that use non-standard
+// sequences. Attributes and colors terminfo can't describe, such as
+// truecolor, and any lookup that fails fall back to ANSI.
+func SetUseTerminfo(enabled bool) {
+	terminfoLock.Lock()
+	defer terminfoLock.Unlock()
+	useTerminfo = enabled
+}
+
+// terminfoEnabled reports whether SetUseTerminfo is on
+func terminfoEnabled() bool {
+	terminfoLock.RLock()
+	defer terminfoLock.RUnlock()
+	return useTerminfo
+}
+
+// terminfoCapabilities maps SGR attribute codes to terminfo capabilities
+var terminfoCapabilities = map[int]string{
+	0: "sgr0",
+	1: "bold",
+	2: "dim",
+	3: "sitm",
+	4: "smul",
+	5: "blink",
+	7: "rev",
+	8: "invis",
+}
+
+// terminfoEscape translates SGR parameters into the terminal's own
+// sequences, one parameter at a time
+func terminfoEscape(code string) string {
+	var out strings.Builder
+
+	fields := strings.Split(code, ";")
+	for i := 0; i < len(fields); i++ {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			continue
+		}
+
+		var capability []string
+		consumed := 1
+		switch {
+		case terminfoCapabilities[n] != "":
+			capability = []string{terminfoCapabilities[n]}
+		case n >= 30 && n <= 37:
+			capability = []string{"setaf", strconv.Itoa(n - 30)}
+		case n >= 90 && n <= 97:
+			capability = []string{"setaf", strconv.Itoa(n - 90 + 8)}
+		case n >= 40 && n <= 47:
+			capability = []string{"setab", strconv.Itoa(n - 40)}
+		case n >= 100 && n <= 107:
+			capability = []string{"setab", strconv.Itoa(n - 100 + 8)}
+		case (n == 38 || n == 48) && i+2 < len(fields) && fields[i+1] == "5":
+			name := "setaf"
+			if n == 48 {
+				name = "setab"
+			}
+			capability = []string{name, fields[i+2]}
+			consumed = 3
+		case (n == 38 || n == 48) && i+4 < len(fields) && fields[i+1] == "2":
+			consumed = 5 // Truecolor has no terminfo capability
+		}
+
+		param := strings.Join(fields[i:i+consumed], ";")
+		i += consumed - 1
+
+		if seq, ok := lookupTerminfo(capability); ok {
+			out.WriteString(seq)
+			continue
+		}
+		out.WriteString("\033[" + param + "m")
+	}
+
+	return out.String()
+}
+
+// lookupTerminfo returns the sequence for a terminfo capability, caching
+// the result of each tput call
+func lookupTerminfo(capability []string) (string, bool) {
+	if len(capability) == 0 {
+		return "", false
+	}
+	key := strings.Join(capability, " ")
+
+	terminfoLock.RLock()
+	seq, cached := terminfoCache[key]
+	terminfoLock.RUnlock()
+	if cached {
+		return seq, seq != ""
+	}
+
+	// An empty result means the lookup failed, so fall back to ANSI
+	out, err := exec.Command("tput", capability...).Output()
+	if err == nil {
+		seq = string(out)
+	}
+
+	terminfoLock.Lock()
+	terminfoCache[key] = seq
+	terminfoLock.Unlock()
+
+	return seq, seq != ""
+}
@@ -0,0 +1,74 @@
+package color
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// =============================================================================
+// FORMATTABLE TEXT
+// =============================================================================
+
+// Text is a string paired with a color function. It implements fmt.Stringer
+// and fmt.Formatter, coloring itself only when color is enabled for stdout,
+// so it can be passed straight to fmt.Println and friends.
+type Text struct {
+	text      string
+	colorFunc func(string) string
+}
+
+// NewText returns text drawn with colorFunc (nil = no color)
+func NewText(text string, colorFunc func(string) string) Text {
+	return Text{text: text, colorFunc: colorFunc}
+}
+
+// Plain returns the text without color
+func (t Text) Plain() string {
+	return t.text
+}
+
+// String returns the text, colored if stdout supports it
+func (t Text) String() string {
+	return t.render(t.text)
+}
+
+// Format implements fmt.Formatter. %s and %v print the text as String
+// does, with width and precision measured on the visible text so padding
+// lines up and is never colored. %#s and %#v always print plain text, and
+// %q prints the plain text quoted.
+func (t Text) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		text := t.text
+		if prec, ok := f.Precision(); ok {
+			text = fmt.Sprintf("%.*s", prec, text)
+		}
+		if !f.Flag('#') {
+			text = t.render(text)
+		}
+
+		pad := ""
+		if width, ok := f.Width(); ok {
+			n := width - utf8.RuneCountInString(StripANSI(text))
+			pad = strings.Repeat(" ", max(0, n))
+		}
+		if f.Flag('-') {
+			fmt.Fprint(f, text, pad)
+		} else {
+			fmt.Fprint(f, pad, text)
+		}
+	case 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), t.text)
+	default:
+		fmt.Fprintf(f, "%%!%c(color.Text=%s)", verb, t.text)
+	}
+}
+
+// render colors text if a color function is set and stdout supports color
+func (t Text) render(text string) string {
+	if t.colorFunc == nil || text == "" {
+		return text
+	}
+	return ConditionalColor(t.colorFunc, text)
+}
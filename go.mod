@@ -2,6 +2,7 @@ module github.com/dreamsofcode-io/termui
 
 go 1.24.1
 
-require golang.org/x/term v0.31.0
-
-require golang.org/x/sys v0.32.0 // indirect
+require (
+	golang.org/x/sys v0.32.0
+	golang.org/x/term v0.31.0
+)
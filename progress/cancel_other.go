@@ -0,0 +1,7 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package progress
+
+// startCancelReader is unsupported on this platform, so
+// WithInteractiveCancel has no effect
+func (b *Bar) startCancelReader() {}
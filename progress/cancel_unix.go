@@ -0,0 +1,92 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package progress
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// cancelPollTimeout is how often, in milliseconds, the keypress reader
+// checks whether the bar has stopped
+const cancelPollTimeout = 100
+
+// startCancelReader puts stdin in cbreak mode and starts reading keypresses
+// for WithInteractiveCancel. Only line buffering and echo are turned off, so
+// output processing and signals such as Ctrl+C work as usual. The caller
+// must hold the lock.
+func (b *Bar) startCancelReader() {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return
+	}
+
+	saved, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return
+	}
+
+	cbreak := *saved
+	cbreak.Lflag &^= unix.ICANON | unix.ECHO
+	cbreak.Cc[unix.VMIN] = 1
+	cbreak.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &cbreak); err != nil {
+		return
+	}
+
+	b.restoreTerm = func() { _ = unix.IoctlSetTermios(fd, ioctlWriteTermios, saved) }
+	b.cancelDone = make(chan struct{})
+	go b.readCancelKeys(fd, b.cancelDone)
+}
+
+// readCancelKeys calls OnCancel when 'q' is read from fd. It
+// polls rather than blocking on a read so that it can return on Stop
+// without consuming input meant for the rest of the program.
+func (b *Bar) readCancelKeys(fd int, done chan struct{}) {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	buf := make([]byte, 1)
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		n, err := unix.Poll(fds, cancelPollTimeout)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if n == 0 || fds[0].Revents&unix.POLLIN == 0 {
+			continue
+		}
+
+		// Check again in case the bar stopped while polling
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if _, err := unix.Read(fd, buf); err != nil {
+			return
+		}
+
+		switch buf[0] {
+		case 'q', 'Q':
+			b.lock.RLock()
+			onCancel := b.config.OnCancel
+			b.lock.RUnlock()
+
+			if onCancel != nil {
+				onCancel()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dreamsofcode-io/termui/color"
+)
+
+// =============================================================================
+// DIFF RENDERING
+// =============================================================================
+
+// diff returns what to write to replace the last drawn line with line: the
+// whole line, or with WithDiffRendering only its changed end when that is
+// shorter. Bars in a MultiBar or Group are always redrawn whole. The caller
+// must hold the lock.
+func (b *Bar) diff(line string) string {
+	if !b.config.DiffRender || b.wholeLines || !b.canErase || b.config.Row > 0 || b.config.TwoLine || b.lastLine == "" {
+		return line
+	}
+	return diffLine(b.lastLine, line)
+}
+
+// diffLine returns the shortest of next and a sequence that moves the cursor
+// past the start next shares with prev, restores the SGR style in effect
+// there, and writes the rest of next. Both lines must start with "\r", and
+// next must end by erasing the rest of the line so no old text is left.
+func diffLine(prev, next string) string {
+	if !strings.HasPrefix(prev, "\r") || !strings.HasPrefix(next, "\r") {
+		return next
+	}
+	p, n := prev[1:], next[1:]
+
+	// Walk both lines cell by cell while they agree, tracking the style
+	// set since the last reset
+	col, i := 0, 0
+	var style strings.Builder
+	for i < len(n) {
+		if n[i] == '\033' {
+			end := escapeEnd(n, i)
+			seq := n[i:end]
+			if !strings.HasPrefix(p[i:], seq) || !strings.HasSuffix(seq, "m") {
+				break
+			}
+
+			if seq == "\033[0m" || seq == "\033[m" {
+				style.Reset()
+			} else {
+				style.WriteString(seq)
+			}
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(n[i:])
+		if !strings.HasPrefix(p[i:], n[i:i+size]) {
+			break
+		}
+		col += color.RuneWidth(r)
+		i += size
+	}
+
+	if col == 0 {
+		return next
+	}
+
+	out := fmt.Sprintf("\r\033[%dC%s%s", col, style.String(), n[i:])
+	if len(out) >= len(next) {
+		return next
+	}
+	return out
+}
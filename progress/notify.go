@@ -0,0 +1,79 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// =============================================================================
+// COMPLETION NOTIFICATIONS
+// =============================================================================
+
+// notifyProtocol identifies the desktop notification escape a terminal accepts
+type notifyProtocol int
+
+const (
+	notifyNone   notifyProtocol = iota // No known support
+	notifyOSC777                       // OSC 777 (foot, Ghostty, WezTerm, urxvt)
+	notifyOSC99                        // OSC 99 (kitty)
+)
+
+// detectNotifyProtocol guesses the notification protocol from the
+// environment, since terminals don't answer a query for it
+func detectNotifyProtocol() notifyProtocol {
+	term := os.Getenv("TERM")
+
+	if os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" {
+		return notifyOSC99
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "ghostty", "WezTerm":
+		return notifyOSC777
+	}
+
+	if strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "rxvt") {
+		return notifyOSC777
+	}
+
+	return notifyNone
+}
+
+// notifyComplete rings the bell and sends the desktop notification, if
+// configured. The caller must hold the lock.
+func (b *Bar) notifyComplete() {
+	if b.config.BellOnComplete {
+		fmt.Fprint(b.config.Writer, "\a")
+	}
+
+	if b.config.NotifyTitle == "" {
+		return
+	}
+
+	// Control characters in the text would end the sequence early
+	title := sanitizeNotification(b.config.NotifyTitle)
+	body := sanitizeNotification(b.config.NotifyBody)
+
+	switch detectNotifyProtocol() {
+	case notifyOSC777:
+		fmt.Fprintf(b.config.Writer, "\033]777;notify;%s;%s\033\\", title, body)
+	case notifyOSC99:
+		if body == "" {
+			fmt.Fprintf(b.config.Writer, "\033]99;;%s\033\\", title)
+			return
+		}
+		fmt.Fprintf(b.config.Writer, "\033]99;i=termui:d=0;%s\033\\", title)
+		fmt.Fprintf(b.config.Writer, "\033]99;i=termui:p=body;%s\033\\", body)
+	}
+}
+
+// sanitizeNotification removes control characters from notification text
+func sanitizeNotification(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -2,41 +2,219 @@
 package progress
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
+	"github.com/dreamsofcode-io/termui/color"
 	"golang.org/x/term"
 )
 
 // BarConfig holds configuration options for the progress bar
 type BarConfig struct {
-	Width       int       // Fixed width (0 = auto-detect terminal width)
-	FilledChar  string    // Character for filled portion
-	EmptyChar   string    // Character for empty portion
-	Writer      io.Writer // Output destination
-	ShowPercent bool      // Whether to show percentage
-	ShowETA     bool      // Whether to show estimated time remaining
+	Width         int       // Fixed width (0 = auto-detect terminal width)
+	FilledChar    string    // Character for filled portion
+	EmptyChar     string    // Character for empty portion
+	SecondaryChar string    // Character for the secondary progress portion
+	Writer        io.Writer // Output destination
+	ShowPercent   bool      // Whether to show percentage
+	ShowETA       bool      // Whether to show estimated time remaining
+	ShowElapsed   bool      // Whether to show time elapsed since start
+	TotalBytes    int64     // Total bytes expected (0 = not tracking bytes, <0 = unknown)
+	TotalCount    int       // Total items expected (0 = not counting items)
+	LogWriter     io.Writer // Destination for plain-text milestone lines (nil = disabled)
+	LogInterval   int       // Percentage between logged milestones (0 = 10%)
+
+	// LogFunc is called at each milestone, e.g. to forward progress to slog
+	LogFunc func(pct int, elapsed time.Duration)
+
+	Indeterminate  bool     // Whether to show a pulse instead of progress
+	ActivityFrames []string // Spinner frames shown before the bar (nil = none)
+	Compact        bool     // Whether to show only a spinner and percentage
+
+	PercentPosition PercentPosition // Where the percentage is shown relative to the bar
+	RateMode        RateMode        // How the transfer rate is measured
+
+	PersistOnStop    bool                // Whether to leave the final line on screen when stopped
+	CompletionMarker string              // Symbol appended when stopped at 100% (e.g. "✓")
+	CompletionColor  func(string) string // Color applied to the completion marker (nil = none)
+
+	BellOnComplete bool   // Whether to ring the terminal bell on reaching 100%
+	NotifyTitle    string // Desktop notification title sent on reaching 100% ("" = none)
+	NotifyBody     string // Desktop notification body
+
+	Sparkline bool // Whether to show a sparkline of recent throughput after the bar
+
+	Row       int           // Terminal row to draw at, from 1 (0 = the current line)
+	Col       int           // Terminal column to draw at, from 1, when Row is set
+	TwoLine   bool          // Whether to draw the message on its own line above the bar
+	Timeout   time.Duration // Stop the bar if it hasn't reached 100% in this time (0 = never)
+	OnTimeout func()        // Called when the bar is stopped by Timeout
+	OnCancel  func()        // Called when 'q' is pressed while the bar runs (nil = off)
+
+	// FillColor colors the filled portion for the current progress (nil = none)
+	FillColor func(progress float64, text string) string
+
+	// CIMode prints a new line at each log interval instead of redrawing in
+	// place, for CI logs that show every carriage return as a new line
+	CIMode    bool
+	ciModeSet bool // Whether CIMode was set explicitly rather than detected
+
+	// DiffRender rewrites only the end of the line that changed, rather than
+	// the whole line, to save bytes over slow links
+	DiffRender bool
+
+	// SizeFunc reports the terminal size for auto width (nil = term.GetSize)
+	SizeFunc func(fd int) (width, height int, err error)
+
+	// Decorators append text to the end of the line on each render, in order
+	Decorators []func(state BarState) string
+
+	// Renderer builds the bar line from the current state (nil = DefaultRenderer)
+	Renderer func(state BarState) string
+}
+
+// PercentPosition sets which side of the bar the percentage appears on
+type PercentPosition int
+
+const (
+	PercentRight PercentPosition = iota // "[####----]  42%"
+	PercentLeft                         // " 42% [####----]"
+)
+
+// RateMode sets how the transfer rate is measured
+type RateMode int
+
+const (
+	RateSmoothed RateMode = iota // Moving average of recent rates: stable yet responsive
+	RateInstant                  // Rate over the last interval: responsive but jittery
+	RateAverage                  // Average since the bar started: stable but slow to react
+)
+
+// BarState is a snapshot of a bar's progress, passed to renderers
+type BarState struct {
+	Progress   float64       // Current progress (0.0 to 1.0)
+	Secondary  float64       // Secondary progress, e.g. buffered (0.0 to 1.0)
+	Width      int           // Columns available for the bar itself
+	Elapsed    time.Duration // Time since the bar was started
+	Bytes      int64         // Bytes transferred so far
+	TotalBytes int64         // Total bytes expected (0 = not tracking bytes, <0 = unknown)
+	Rate       float64       // Transfer rate in bytes per second, measured per RateMode
+	Count      int           // Items completed so far
+	TotalCount int           // Total items expected (0 = not counting items)
+	Message    string        // Text shown before the bar, e.g. the current item
+	Samples    []float64     // Recent throughput samples, oldest first, for the sparkline
+	Frame      int           // Animation frame counter for indeterminate bars
+	Config     BarConfig     // Configuration of the bar being rendered
+}
+
+// Indeterminate reports whether the bar shows a pulse rather than progress
+func (s BarState) Indeterminate() bool {
+	return s.Config.Indeterminate || s.TotalBytes < 0
 }
 
 // Bar represents a terminal progress bar
 type Bar struct {
 	config       BarConfig
 	totalWidth   int
+	termWidth    int // Terminal width, or 0 if unknown
+	termHeight   int // Terminal height, or 0 if unknown
+	drawnWidth   int // Visible width of the last line drawn at a fixed position
+	decorWidth   int // Widest decorator output seen, reserved after the bar
 	lastProgress float64
+	secondary    float64
 	started      bool
 	stopped      bool
 	startTime    time.Time
+	paused       bool
+	pauseStart   time.Time
+	bytes        int64
+	count        int
+	message      string
+	pulseFrame   int
+	lastLogged   int
+	lastCILine   int // Percentage of the last line printed in CI mode
+	samples      []float64
+	sampleTime   time.Time // When the last throughput sample was taken
+	sampleValue  float64   // Bytes or progress at the last sample
+	rateTime     time.Time // When the rate was last measured
+	rateBytes    int64     // Bytes at the last rate measurement
+	instantRate  float64   // Bytes per second over the last rate interval
+	smoothedRate float64   // Exponential moving average of instantRate
+	completed    bool      // Whether the completion bell/notification has been sent
+	timedOut     bool
+	timeoutTimer *time.Timer
+	cancelDone   chan struct{} // Closed to stop the keypress reader
+	restoreTerm  func()        // Restores stdin's terminal mode (nil = unchanged)
+	canErase     bool
+	lastLine     string
+	margin       int  // Columns used by text drawn alongside the bar (e.g. labels)
+	wholeLines   bool // Whether every write must be a whole line, for MultiBar and Group
+	doneCh       chan struct{}
 	termSizeCh   chan os.Signal
 	lock         sync.RWMutex
+
+	// Rendering counters, reported by Stats
+	redraws atomic.Uint64
+	dropped atomic.Uint64
+	resizes atomic.Uint64
+}
+
+// Stats reports how much rendering work a bar has done
+type Stats struct {
+	Redraws uint64 // Lines written to the terminal
+	Dropped uint64 // Updates skipped because the line hadn't changed
+	Resizes uint64 // Terminal resize events handled
 }
 
+const (
+	// pulseInterval is the redraw interval for the indeterminate animation
+	pulseInterval = 100 * time.Millisecond
+
+	// sparklineWidth is the number of throughput samples in the sparkline
+	sparklineWidth = 8
+
+	// sparklineInterval is the minimum time between throughput samples
+	sparklineInterval = 500 * time.Millisecond
+
+	// rateInterval is the minimum time between rate measurements
+	rateInterval = 250 * time.Millisecond
+
+	// rateSmoothing is the weight of each new measurement in the smoothed
+	// rate, from 0 (never changes) to 1 (same as instant)
+	rateSmoothing = 0.3
+
+	// defaultLogInterval is the default percentage between logged milestones
+	defaultLogInterval = 10
+
+	// eraseLine clears from the cursor to the end of the line
+	eraseLine = "\033[K"
+
+	// cursorUp moves the cursor up one line
+	cursorUp = "\033[1A"
+
+	// saveCursor and restoreCursor bracket lines drawn with WithPosition
+	saveCursor    = "\0337"
+	restoreCursor = "\0338"
+)
+
+// ErrRunning is returned by Configure when the bar is running
+var ErrRunning = errors.New("progress: bar is running")
+
+// getSize reports the terminal size, and can be replaced to simulate
+// different terminal widths
+var getSize = term.GetSize
+
 // Option represents a configuration option for the progress bar
 type Option func(*BarConfig)
 
@@ -47,6 +225,14 @@ func WithWidth(width int) Option {
 	}
 }
 
+// WithSizeFunc sets the function used to measure the terminal when
+// auto-detecting the width, in place of term.GetSize
+func WithSizeFunc(fn func(fd int) (width, height int, err error)) Option {
+	return func(c *BarConfig) {
+		c.SizeFunc = fn
+	}
+}
+
 // WithFilledChar sets the character used for the filled portion
 func WithFilledChar(char string) Option {
 	return func(c *BarConfig) {
@@ -54,6 +240,14 @@ func WithFilledChar(char string) Option {
 	}
 }
 
+// WithFillColor sets a function that colors the filled portion, given the
+// current progress (see GradientFill)
+func WithFillColor(fn func(progress float64, text string) string) Option {
+	return func(c *BarConfig) {
+		c.FillColor = fn
+	}
+}
+
 // WithEmptyChar sets the character used for the empty portion
 func WithEmptyChar(char string) Option {
 	return func(c *BarConfig) {
@@ -61,6 +255,14 @@ func WithEmptyChar(char string) Option {
 	}
 }
 
+// WithSecondaryChar sets the character used for the secondary progress
+// portion, drawn between the filled and empty portions
+func WithSecondaryChar(char string) Option {
+	return func(c *BarConfig) {
+		c.SecondaryChar = char
+	}
+}
+
 // WithWriter sets the output destination
 func WithWriter(writer io.Writer) Option {
 	return func(c *BarConfig) {
@@ -68,6 +270,32 @@ func WithWriter(writer io.Writer) Option {
 	}
 }
 
+// WithWriters sets separate destinations for the animated bar and for a
+// plain-text log. The bar animates on ttyWriter as usual, while a progress
+// line is written to logWriter at each milestone (every 10% by default).
+func WithWriters(ttyWriter, logWriter io.Writer) Option {
+	return func(c *BarConfig) {
+		c.Writer = ttyWriter
+		c.LogWriter = logWriter
+	}
+}
+
+// WithLogFunc sets a function called at each milestone with the percentage
+// reached and the time elapsed, for forwarding progress to structured logs
+func WithLogFunc(fn func(pct int, elapsed time.Duration)) Option {
+	return func(c *BarConfig) {
+		c.LogFunc = fn
+	}
+}
+
+// WithLogInterval sets the percentage between milestones reported to the
+// LogWriter and LogFunc
+func WithLogInterval(percent int) Option {
+	return func(c *BarConfig) {
+		c.LogInterval = percent
+	}
+}
+
 // WithPercent enables/disables percentage display
 func WithPercent(show bool) Option {
 	return func(c *BarConfig) {
@@ -75,6 +303,13 @@ func WithPercent(show bool) Option {
 	}
 }
 
+// WithPercentPosition sets which side of the bar the percentage is shown on
+func WithPercentPosition(position PercentPosition) Option {
+	return func(c *BarConfig) {
+		c.PercentPosition = position
+	}
+}
+
 // WithETA enables/disables estimated time remaining
 func WithETA(show bool) Option {
 	return func(c *BarConfig) {
@@ -82,41 +317,284 @@ func WithETA(show bool) Option {
 	}
 }
 
+// WithDecorator adds a function whose result is appended to the end of the
+// line on each render, e.g. " retry 2/5". Decorators are applied in the
+// order they are added, and the space they need is taken from the bar.
+func WithDecorator(decorate func(state BarState) string) Option {
+	return func(c *BarConfig) {
+		c.Decorators = append(c.Decorators, decorate)
+	}
+}
+
+// WithRenderer replaces the function used to build the bar line. The bar
+// still handles its lifecycle, locking, resizing and clearing, and writes the
+// returned string after a carriage return.
+func WithRenderer(render func(state BarState) string) Option {
+	return func(c *BarConfig) {
+		c.Renderer = render
+	}
+}
+
+// WithIndeterminate shows a pulse moving back and forth instead of progress,
+// for work whose length can't be measured
+func WithIndeterminate(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.Indeterminate = enabled
+	}
+}
+
+// WithActivitySpinner shows a spinning glyph before the bar that advances on
+// the same ticker as the indeterminate pulse (e.g. "⣾ [   ███   ]")
+func WithActivitySpinner(frames []string) Option {
+	return func(c *BarConfig) {
+		c.ActivityFrames = frames
+	}
+}
+
+// WithCompact replaces the bar with a minimal animated indicator made of a
+// spinner glyph and the percentage (e.g. "⣾ 42%"), for tight layouts. The
+// glyph uses the activity spinner frames if set.
+func WithCompact(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.Compact = enabled
+	}
+}
+
+// WithElapsed enables/disables display of the time elapsed since start. When
+// combined with WithETA both are shown (e.g. "01:23 / ETA 00:45").
+func WithElapsed(show bool) Option {
+	return func(c *BarConfig) {
+		c.ShowElapsed = show
+	}
+}
+
+// WithPersistOnStop leaves the bar's final line on screen when it is stopped
+// instead of clearing it
+func WithPersistOnStop(persist bool) Option {
+	return func(c *BarConfig) {
+		c.PersistOnStop = persist
+	}
+}
+
+// WithCompletionMarker appends a symbol to the final line when the bar is
+// stopped at 100% (e.g. "[########] 100% ✓"), colored with colorFunc if not
+// nil. A completed bar with a marker is kept on screen.
+func WithCompletionMarker(symbol string, colorFunc func(string) string) Option {
+	return func(c *BarConfig) {
+		c.CompletionMarker = symbol
+		c.CompletionColor = colorFunc
+	}
+}
+
+// WithBellOnComplete rings the terminal bell when the bar reaches 100%
+func WithBellOnComplete(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.BellOnComplete = enabled
+	}
+}
+
+// WithOSCNotification sends a desktop notification when the bar reaches
+// 100%, on terminals known to support OSC 777 or OSC 99 notifications.
+// Other terminals are left alone.
+func WithOSCNotification(title, body string) Option {
+	return func(c *BarConfig) {
+		c.NotifyTitle = title
+		c.NotifyBody = body
+	}
+}
+
+// WithRateMode sets how the transfer rate shown for byte-tracking bars is
+// measured (see RateMode). The default, RateSmoothed, avoids the jitter of
+// RateInstant while reacting to changes faster than RateAverage.
+func WithRateMode(mode RateMode) Option {
+	return func(c *BarConfig) {
+		c.RateMode = mode
+	}
+}
+
+// WithSparkline shows a sparkline of recent throughput (bytes per second
+// when tracking bytes, otherwise progress per second) after the bar, so you
+// can see whether the speed is rising or falling
+func WithSparkline(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.Sparkline = enabled
+	}
+}
+
+// WithPosition draws the bar at a fixed terminal position (row and column
+// counting from 1) instead of on the current line, for embedding it in a
+// full-screen interface. The cursor is saved and restored around each draw,
+// and the position is clamped to the terminal size when it shrinks.
+func WithPosition(row, col int) Option {
+	return func(c *BarConfig) {
+		c.Row = row
+		c.Col = col
+	}
+}
+
+// WithDiffRendering rewrites only the part of the line that changed since
+// the last draw, moving the cursor past the unchanged start, which cuts the
+// bytes sent over slow links such as high-latency SSH. The whole line is
+// redrawn when that would be as short, after a resize, and always for bars
+// drawn with WithPosition or WithTwoLine, or in a MultiBar or Group.
+func WithDiffRendering(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.DiffRender = enabled
+	}
+}
+
+// WithTwoLine draws the message set with SetMessage on its own line above
+// the bar instead of in a column before it, so long descriptions don't need
+// to share the bar's line. The message is truncated to the terminal width.
+func WithTwoLine(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.TwoLine = enabled
+	}
+}
+
+// WithTimeout stops the bar if it hasn't reached 100% within d, guarding
+// against stuck operations leaving a frozen bar. When the final line is kept
+// (see WithPersistOnStop) it is marked with a red ✗.
+func WithTimeout(d time.Duration) Option {
+	return func(c *BarConfig) {
+		c.Timeout = d
+	}
+}
+
+// WithOnTimeout sets a function to call when the bar is stopped by
+// WithTimeout. It is not called when the bar is stopped normally.
+func WithOnTimeout(fn func()) Option {
+	return func(c *BarConfig) {
+		c.OnTimeout = fn
+	}
+}
+
+// WithInteractiveCancel reads keypresses from stdin while the bar runs and
+// calls fn when 'q' is pressed, letting users abort the operation from the
+// bar. Stdin is put in cbreak mode (no line buffering or echo), leaving
+// output and signals such as Ctrl+C unaffected. The terminal is restored by
+// Stop, so defer Stop to keep it usable if the operation panics. It has no
+// effect when stdin isn't a terminal or in CI mode.
+func WithInteractiveCancel(fn func()) Option {
+	return func(c *BarConfig) {
+		c.OnCancel = fn
+	}
+}
+
+// WithCIMode prints a new line at each log interval (every 10% by default,
+// see WithLogInterval) with no carriage returns or animation, so CI logs
+// stay readable. It is enabled automatically when a CI environment variable
+// such as CI or GITHUB_ACTIONS is set.
+func WithCIMode(enabled bool) Option {
+	return func(c *BarConfig) {
+		c.CIMode = enabled
+		c.ciModeSet = true
+	}
+}
+
+// WithTotalBytes sets the number of bytes expected. Progress is then derived
+// from the bytes reported via AddBytes/SetBytes. A negative total means the
+// size is unknown, in which case the bar renders an indeterminate animation
+// with the transferred bytes and rate instead of a percentage and ETA.
+func WithTotalBytes(total int64) Option {
+	return func(c *BarConfig) {
+		c.TotalBytes = total
+	}
+}
+
+// WithCount sets the number of items expected. Progress is then derived
+// from the count reported via SetCount/IncrementCount, and the line shows
+// the count alongside the percentage (e.g. "40% (4/10)").
+func WithCount(total int) Option {
+	return func(c *BarConfig) {
+		c.TotalCount = total
+	}
+}
+
+// WithUnknownTotal marks the total size as unknown (see WithTotalBytes)
+func WithUnknownTotal() Option {
+	return WithTotalBytes(-1)
+}
+
+// defaultActivityFrames are the spinner frames used by compact bars when no
+// activity spinner frames are configured
+var defaultActivityFrames = []string{"⣾", "⣽", "⣻", "⢿", "⡿", "⣟", "⣯", "⣷"}
+
 // Predefined styles
 var (
 	StyleDefault = BarConfig{
-		FilledChar:  "#",
-		EmptyChar:   " ",
-		Writer:      os.Stdout,
-		ShowPercent: true,
-		ShowETA:     false,
+		FilledChar:    "#",
+		EmptyChar:     " ",
+		SecondaryChar: "=",
+		Writer:        os.Stdout,
+		ShowPercent:   true,
+		ShowETA:       false,
 	}
 
 	StyleBlocks = BarConfig{
-		FilledChar:  "█",
-		EmptyChar:   "░",
-		Writer:      os.Stdout,
-		ShowPercent: true,
-		ShowETA:     false,
+		FilledChar:    "█",
+		EmptyChar:     "░",
+		SecondaryChar: "▒",
+		Writer:        os.Stdout,
+		ShowPercent:   true,
+		ShowETA:       false,
 	}
 
 	StyleDots = BarConfig{
-		FilledChar:  "●",
-		EmptyChar:   "○",
-		Writer:      os.Stdout,
-		ShowPercent: true,
-		ShowETA:     false,
+		FilledChar:    "●",
+		EmptyChar:     "○",
+		SecondaryChar: "◎",
+		Writer:        os.Stdout,
+		ShowPercent:   true,
+		ShowETA:       false,
+	}
+
+	// StyleGradient colors the filled portion from red through yellow to
+	// green as progress increases
+	StyleGradient = BarConfig{
+		FilledChar:    "█",
+		EmptyChar:     "░",
+		SecondaryChar: "▒",
+		FillColor:     GradientFill,
+		Writer:        os.Stdout,
+		ShowPercent:   true,
+		ShowETA:       false,
 	}
 
 	StyleMinimal = BarConfig{
-		FilledChar:  "=",
-		EmptyChar:   "-",
-		Writer:      os.Stdout,
-		ShowPercent: false,
-		ShowETA:     false,
+		FilledChar:    "=",
+		EmptyChar:     "-",
+		SecondaryChar: "~",
+		Writer:        os.Stdout,
+		ShowPercent:   false,
+		ShowETA:       false,
 	}
 )
 
+// Colors used by GradientFill
+var (
+	gradientRed    = [3]int{220, 50, 47}
+	gradientYellow = [3]int{230, 190, 0}
+	gradientGreen  = [3]int{40, 180, 60}
+)
+
+// GradientFill colors text along a red, yellow, green gradient for the
+// given progress. Terminals without 256-color support get plain green, and
+// nothing is colored when NO_COLOR is set or output isn't a terminal.
+func GradientFill(progress float64, text string) string {
+	return color.ConditionalColor(func(text string) string {
+		if color.GetColorLevel() < color.ColorLevel256 {
+			return color.Green(text)
+		}
+
+		c := color.Mix(gradientRed, gradientYellow, progress*2)
+		if progress > 0.5 {
+			c = color.Mix(gradientYellow, gradientGreen, progress*2-1)
+		}
+		return color.Best(c[0], c[1], c[2], text)
+	}, text)
+}
+
 // NewBar creates a new progress bar with default configuration
 func NewBar() *Bar {
 	return NewBarWithConfig(StyleDefault)
@@ -133,71 +611,177 @@ func NewBarWithConfig(config BarConfig, opts ...Option) *Bar {
 	for _, opt := range opts {
 		opt(&config)
 	}
+	setDefaults(&config)
+
+	if !config.ciModeSet {
+		config.CIMode = detectCI()
+	}
+
+	b := &Bar{
+		config:       config,
+		lastProgress: 0,
+		canErase:     supportsErase(),
+		termSizeCh:   make(chan os.Signal, 1),
+	}
+
+	b.calculateWidth()
+	return b
+}
+
+// ciEnvVars are environment variables set by common CI systems
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "JENKINS_URL", "TF_BUILD"}
+
+// detectCI reports whether the program appears to be running under CI
+func detectCI() bool {
+	for _, name := range ciEnvVars {
+		if value := os.Getenv(name); value != "" && value != "false" && value != "0" {
+			return true
+		}
+	}
+	return false
+}
 
-	// Set defaults if not specified
+// setDefaults fills in defaults for unset configuration values
+func setDefaults(config *BarConfig) {
 	if config.FilledChar == "" {
 		config.FilledChar = "#"
 	}
 	if config.EmptyChar == "" {
 		config.EmptyChar = " "
 	}
+	if config.SecondaryChar == "" {
+		config.SecondaryChar = "."
+	}
 	if config.Writer == nil {
 		config.Writer = os.Stdout
 	}
+}
 
-	b := &Bar{
-		config:       config,
-		lastProgress: 0,
-		termSizeCh:   make(chan os.Signal, 1),
+// Configure applies options to an existing bar, e.g. to change its
+// appearance between phases of work. The bar must not be running; Configure
+// returns ErrRunning between Start and Stop.
+func (b *Bar) Configure(opts ...Option) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.started && !b.stopped {
+		return ErrRunning
 	}
 
+	for _, opt := range opts {
+		opt(&b.config)
+	}
+	setDefaults(&b.config)
 	b.calculateWidth()
-	return b
+
+	return nil
 }
 
 // calculateWidth determines the width of the progress bar
 func (b *Bar) calculateWidth() {
+	// Auto-detect terminal width
+	sizeFunc := b.config.SizeFunc
+	if sizeFunc == nil {
+		sizeFunc = getSize
+	}
+
+	width, height, err := sizeFunc(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 0, 0
+	}
+	b.termWidth, b.termHeight = width, height
+
 	if b.config.Width > 0 {
 		b.totalWidth = b.config.Width
 		return
 	}
 
-	// Auto-detect terminal width
-	width, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
 		b.totalWidth = 60 // Fallback width
 		return
 	}
 
-	// Reserve space for percentage and ETA
-	reservedSpace := 0
-	if b.config.ShowPercent {
-		reservedSpace += 5 // " 100%"
-	}
-	if b.config.ShowETA {
-		reservedSpace += 12 // " ETA: 00:00"
+	if b.config.Row > 0 {
+		width -= max(1, b.config.Col) - 1 // Columns left of the fixed position
 	}
 
-	b.totalWidth = width - reservedSpace - 2 // -2 for brackets or margins
+	b.totalWidth = width - b.reservedWidth() - b.margin - 2 // -2 for brackets or margins
 	if b.totalWidth < 10 {
 		b.totalWidth = 10 // Minimum width
 	}
 }
 
+// reservedWidth returns the space needed after the bar for its annotations
+func (b *Bar) reservedWidth() int {
+	reservedSpace := b.decorWidth
+	if len(b.config.ActivityFrames) > 0 {
+		reservedSpace += 2 // "⣾ "
+	}
+
+	if b.isIndeterminate() {
+		if b.config.TotalBytes < 0 {
+			reservedSpace += 24 // " 1000.0 MB  1000.0 MB/s"
+		}
+		return reservedSpace
+	}
+
+	if b.config.ShowPercent {
+		reservedSpace += 5 // " 100%"
+	}
+	if b.config.TotalCount > 0 {
+		digits := len(strconv.Itoa(b.config.TotalCount))
+		reservedSpace += 2*digits + 4 // " (10/10)"
+	}
+	if b.config.Sparkline {
+		reservedSpace += sparklineWidth + 1 // " ▁▂▃▄▅▆▇█"
+	}
+	switch {
+	case b.config.ShowETA && b.config.ShowElapsed:
+		reservedSpace += 24 // " 00:00:00 / ETA 00:00:00"
+	case b.config.ShowETA:
+		reservedSpace += 14 // " ETA: 00:00:00"
+	case b.config.ShowElapsed:
+		reservedSpace += 9 // " 00:00:00"
+	}
+	return reservedSpace
+}
+
+// isIndeterminate reports whether the bar shows a pulse rather than progress
+func (b *Bar) isIndeterminate() bool {
+	return b.config.Indeterminate || b.config.TotalBytes < 0
+}
+
+// isAnimated reports whether the bar needs redrawing on a timer
+func (b *Bar) isAnimated() bool {
+	return b.isIndeterminate() || b.config.Compact || len(b.config.ActivityFrames) > 0
+}
+
 // clearLine clears the current terminal line
 func (b *Bar) clearLine() {
-	totalClearWidth := b.totalWidth
-	if b.config.ShowPercent {
-		totalClearWidth += 5
+	b.lastLine = ""
+
+	if b.config.Row > 0 {
+		fmt.Fprint(b.config.Writer, b.positioned(""))
+		return
 	}
-	if b.config.ShowETA {
-		totalClearWidth += 12
+
+	if b.canErase {
+		fmt.Fprint(b.config.Writer, "\r"+eraseLine)
+		return
 	}
 
+	// Fall back to overwriting with spaces on terminals without erase support
+	totalClearWidth := b.totalWidth + b.reservedWidth()
+
 	empty := strings.Repeat(" ", totalClearWidth+5) // +5 for safety margin
 	fmt.Fprintf(b.config.Writer, "\r%s", empty)
 }
 
+// supportsErase reports whether the terminal understands erase-in-line
+func supportsErase() bool {
+	return os.Getenv("TERM") != "dumb"
+}
+
 // Start initializes the progress bar
 func (b *Bar) Start() {
 	b.lock.Lock()
@@ -210,14 +794,99 @@ func (b *Bar) Start() {
 	b.started = true
 	b.stopped = false
 	b.startTime = time.Now()
-	b.clearLine()
+	b.paused = false
 	b.lastProgress = 0
+	b.bytes = 0
+	b.count = 0
+	b.lastLogged = 0
+	b.lastCILine = 0
+	b.completed = false
+	b.samples = nil
+	b.sampleTime = time.Time{}
+	b.rateTime = time.Time{}
+	b.rateBytes = 0
+	b.instantRate = 0
+	b.smoothedRate = 0
+	b.timedOut = false
+
+	if b.config.Timeout > 0 {
+		b.timeoutTimer = time.AfterFunc(b.config.Timeout, b.handleTimeout)
+	}
+
+	// Lines are only ever appended in CI mode
+	if b.config.CIMode {
+		return
+	}
+	b.clearLine()
+
+	if b.config.OnCancel != nil {
+		b.startCancelReader()
+	}
+
+	// Leave the current line for the message and draw the bar below it
+	if b.config.TwoLine {
+		fmt.Fprint(b.config.Writer, "\n")
+	}
 
 	// Set up terminal resize handling if using auto-width
 	if b.config.Width == 0 {
 		signal.Notify(b.termSizeCh, syscall.SIGWINCH)
 		go b.handleResize()
 	}
+
+	// Animate the pulse and activity spinner from a single ticker
+	if b.isAnimated() {
+		b.doneCh = make(chan struct{})
+		go b.animate(b.doneCh)
+	}
+}
+
+// animate redraws the bar on a fixed interval
+func (b *Bar) animate(doneCh chan struct{}) {
+	ticker := time.NewTicker(pulseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.lock.Lock()
+			if b.stopped {
+				b.lock.Unlock()
+				return
+			}
+			if b.paused {
+				b.lock.Unlock()
+				continue // Keep the last line on screen
+			}
+			b.pulseFrame++
+			line := b.renderLine()
+			fmt.Fprint(b.config.Writer, b.diff(line))
+			b.lastLine = line
+			b.redraws.Add(1)
+			b.lock.Unlock()
+
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// handleTimeout stops the bar if it is still running when the timeout set
+// with WithTimeout elapses
+func (b *Bar) handleTimeout() {
+	b.lock.Lock()
+	if b.stopped || b.lastProgress >= 1.0 {
+		b.lock.Unlock()
+		return
+	}
+	b.timedOut = true
+	b.stop()
+	onTimeout := b.config.OnTimeout
+	b.lock.Unlock()
+
+	if onTimeout != nil {
+		onTimeout()
+	}
 }
 
 // handleResize manages terminal window resize events
@@ -231,6 +900,8 @@ func (b *Bar) handleResize() {
 				return
 			}
 			b.calculateWidth()
+			b.resizes.Add(1)
+			b.lastLine = "" // Force a redraw at the new width
 			progress := b.lastProgress
 			b.lock.Unlock()
 			b.SetProgress(progress) // Redraw with new width
@@ -238,92 +909,602 @@ func (b *Bar) handleResize() {
 	}
 }
 
-// Stop cleans up the progress bar
-func (b *Bar) Stop() {
-	b.lock.Lock()
-	defer b.lock.Unlock()
+// Stop cleans up the progress bar
+func (b *Bar) Stop() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.stop()
+}
+
+// stop stops the bar. The caller must hold the lock.
+func (b *Bar) stop() {
+	if b.stopped {
+		return
+	}
+
+	b.stopped = true
+
+	if b.timeoutTimer != nil {
+		b.timeoutTimer.Stop()
+		b.timeoutTimer = nil
+	}
+
+	if b.doneCh != nil {
+		close(b.doneCh)
+		b.doneCh = nil
+	}
+
+	if b.cancelDone != nil {
+		close(b.cancelDone)
+		b.cancelDone = nil
+	}
+	if b.restoreTerm != nil {
+		b.restoreTerm()
+		b.restoreTerm = nil
+	}
+
+	if b.config.CIMode {
+		return // Nothing to clean up
+	}
+
+	// Clean up signal handling
+	if b.config.Width == 0 {
+		signal.Stop(b.termSizeCh)
+	}
+
+	if b.shouldPersist() {
+		b.persistLine()
+		return
+	}
+
+	b.clearLine()
+	if b.config.Row > 0 {
+		return // The cursor was never moved
+	}
+	if b.config.TwoLine {
+		fmt.Fprint(b.config.Writer, cursorUp+"\r"+eraseLine)
+	}
+	fmt.Fprintf(b.config.Writer, "\r")
+}
+
+// Finish sets the progress to 100% and stops the bar
+func (b *Bar) Finish() {
+	b.SetProgress(1.0)
+	b.Stop()
+}
+
+// shouldPersist reports whether Stop should leave the final line on screen.
+// The caller must hold the lock.
+func (b *Bar) shouldPersist() bool {
+	return b.started && (b.config.PersistOnStop || b.showCompletion())
+}
+
+// showCompletion reports whether the completion marker should be drawn. The
+// caller must hold the lock.
+func (b *Bar) showCompletion() bool {
+	return b.config.CompletionMarker != "" && b.lastProgress >= 1.0
+}
+
+// persistLine redraws the final line, with the completion marker if the bar
+// finished, and moves to the next line. The caller must hold the lock.
+func (b *Bar) persistLine() {
+	line := "\r" + b.render()
+	if b.showCompletion() {
+		marker := b.config.CompletionMarker
+		if b.config.CompletionColor != nil {
+			marker = b.config.CompletionColor(marker)
+		}
+		line += " " + marker
+	}
+	if b.timedOut {
+		line += " " + color.ConditionalColor(color.Red, "✗")
+	}
+
+	if b.config.Row > 0 {
+		fmt.Fprint(b.config.Writer, b.positioned(strings.TrimPrefix(line, "\r")))
+		b.lastLine = ""
+		return
+	}
+
+	if b.canErase {
+		line += eraseLine
+	}
+
+	fmt.Fprint(b.config.Writer, line+"\n")
+	b.lastLine = ""
+}
+
+// SetProgress updates the progress (0.0 to 1.0)
+func (b *Bar) SetProgress(progress float64) {
+	b.update(func() float64 { return progress })
+}
+
+// update sets the progress to the value returned by next and redraws. next
+// is called with the lock held, so updates relative to the current state
+// can't race with each other.
+func (b *Bar) update(next func() float64) {
+	b.lock.Lock()
+	progress := next()
+	if !b.started || b.stopped {
+		b.lock.Unlock()
+		return
+	}
+
+	progress = clampProgress(progress)
+
+	b.lastProgress = progress
+
+	if b.config.CIMode {
+		b.printCILine()
+	} else {
+		b.redraw()
+	}
+
+	if progress >= 1.0 && !b.completed {
+		b.completed = true
+		b.notifyComplete()
+	}
+
+	milestone := b.nextMilestone()
+	elapsed := b.elapsed()
+	b.lock.Unlock()
+
+	if milestone > 0 {
+		if b.config.LogWriter != nil {
+			fmt.Fprintf(b.config.LogWriter, "progress: %3d%% (elapsed %s)\n",
+				milestone, elapsed.Round(time.Second))
+		}
+		if b.config.LogFunc != nil {
+			b.config.LogFunc(milestone, elapsed)
+		}
+	}
+}
+
+// clampProgress constrains progress to the range 0.0 to 1.0. NaN and
+// infinities, as produced by dividing by a zero total, are treated as 0.
+func clampProgress(progress float64) float64 {
+	if math.IsNaN(progress) || math.IsInf(progress, 0) {
+		return 0
+	}
+	return math.Max(0.0, math.Min(1.0, progress))
+}
+
+// nextMilestone returns the newly reached log milestone percentage, or 0 if
+// no line should be logged. The caller must hold the lock.
+func (b *Bar) nextMilestone() int {
+	if (b.config.LogWriter == nil && b.config.LogFunc == nil) || b.isIndeterminate() {
+		return 0
+	}
+
+	interval := b.config.LogInterval
+	if interval <= 0 {
+		interval = defaultLogInterval
+	}
+
+	milestone := int(b.lastProgress*100) / interval * interval
+	if milestone <= b.lastLogged {
+		return 0
+	}
+
+	b.lastLogged = milestone
+	return milestone
+}
+
+// state returns a snapshot of the bar for rendering. The caller must hold
+// the lock.
+func (b *Bar) state() BarState {
+	elapsed := b.elapsed()
+	b.measureRate()
+
+	return BarState{
+		Progress:   b.lastProgress,
+		Secondary:  b.secondary,
+		Width:      b.totalWidth,
+		Elapsed:    elapsed,
+		Bytes:      b.bytes,
+		TotalBytes: b.config.TotalBytes,
+		Rate:       b.rate(),
+		Count:      b.count,
+		TotalCount: b.config.TotalCount,
+		Message:    b.message,
+		Samples:    b.samples,
+		Frame:      b.pulseFrame,
+		Config:     b.config,
+	}
+}
+
+// Rate returns the transfer rate in bytes per second, measured as set with
+// WithRateMode
+func (b *Bar) Rate() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.measureRate()
+	return b.rate()
+}
+
+// rate returns the transfer rate for the configured mode, using the average
+// until the first interval has been measured. The caller must hold the lock.
+func (b *Bar) rate() float64 {
+	measured := !b.rateTime.IsZero()
+	switch {
+	case b.config.RateMode == RateInstant && measured:
+		return b.instantRate
+	case b.config.RateMode == RateSmoothed && measured:
+		return b.smoothedRate
+	}
+
+	if elapsed := b.elapsed(); elapsed > 0 {
+		return float64(b.bytes) / elapsed.Seconds()
+	}
+	return 0
+}
+
+// measureRate updates the instant and smoothed rates if enough time has
+// passed since the last measurement. The rates are held while paused. The
+// caller must hold the lock.
+func (b *Bar) measureRate() {
+	if !b.started || b.paused {
+		return
+	}
+
+	since := b.rateTime
+	if since.IsZero() {
+		since = b.startTime
+	}
+
+	now := time.Now()
+	dt := now.Sub(since)
+	if dt < rateInterval {
+		return
+	}
+
+	b.instantRate = float64(b.bytes-b.rateBytes) / dt.Seconds()
+	if b.rateTime.IsZero() {
+		b.smoothedRate = b.instantRate
+	} else {
+		b.smoothedRate += rateSmoothing * (b.instantRate - b.smoothedRate)
+	}
+	b.rateTime, b.rateBytes = now, b.bytes
+}
+
+// render renders the current state with the configured renderer. The
+// caller must hold the lock.
+func (b *Bar) render() string {
+	if b.config.Sparkline {
+		b.sample()
+	}
+
+	render := b.config.Renderer
+	if render == nil {
+		render = DefaultRenderer
+	}
+
+	state := b.state()
+	if b.config.TwoLine {
+		state.Message = "" // Drawn on its own line by renderLine
+	}
+
+	var decorations strings.Builder
+	for _, decorate := range b.config.Decorators {
+		decorations.WriteString(decorate(state))
+	}
+
+	// Make room when decorations grow, keeping the widest seen so the bar
+	// doesn't change size every time they shrink
+	if width := visibleWidth(decorations.String()); width > b.decorWidth {
+		b.decorWidth = width
+		b.calculateWidth()
+		state.Width = b.totalWidth
+	}
+
+	return render(state) + decorations.String()
+}
+
+// sample records the throughput since the last sample, in bytes per second
+// when tracking bytes and progress per second otherwise, if enough time has
+// passed. The caller must hold the lock.
+func (b *Bar) sample() {
+	now := time.Now()
+	value := b.lastProgress
+	if b.config.TotalBytes != 0 {
+		value = float64(b.bytes)
+	}
+
+	if b.sampleTime.IsZero() {
+		b.sampleTime, b.sampleValue = now, value
+		return
+	}
+
+	dt := now.Sub(b.sampleTime)
+	if dt < sparklineInterval {
+		return
+	}
+
+	b.samples = append(b.samples, (value-b.sampleValue)/dt.Seconds())
+	if len(b.samples) > sparklineWidth {
+		b.samples = b.samples[len(b.samples)-sparklineWidth:]
+	}
+	b.sampleTime, b.sampleValue = now, value
+}
+
+// sparklineLevels are the glyphs used for sparkline samples, lowest first
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws samples scaled to the largest one, padded to
+// sparklineWidth so the line doesn't shift as samples accumulate
+func renderSparkline(samples []float64) string {
+	peak := 0.0
+	for _, v := range samples {
+		peak = math.Max(peak, v)
+	}
+
+	var line strings.Builder
+	for _, v := range samples {
+		level := 0
+		if peak > 0 {
+			level = int(math.Max(0, v) / peak * float64(len(sparklineLevels)-1))
+		}
+		line.WriteRune(sparklineLevels[level])
+	}
+	line.WriteString(strings.Repeat(" ", sparklineWidth-len(samples)))
+	return line.String()
+}
+
+// printCILine appends a line for the current progress if it has reached a
+// new log interval. The caller must hold the lock.
+func (b *Bar) printCILine() {
+	if b.isIndeterminate() {
+		return
+	}
 
-	if b.stopped {
+	interval := b.config.LogInterval
+	if interval <= 0 {
+		interval = defaultLogInterval
+	}
+
+	pct := int(b.lastProgress * 100)
+	if pct < 100 {
+		pct = pct / interval * interval
+	}
+	if pct <= b.lastCILine {
+		b.dropped.Add(1)
 		return
 	}
 
-	b.stopped = true
+	b.lastCILine = pct
+	fmt.Fprintln(b.config.Writer, b.render())
+	b.redraws.Add(1)
+}
 
-	// Clean up signal handling
-	if b.config.Width == 0 {
-		signal.Stop(b.termSizeCh)
+// renderLine builds the full line for the current state. The caller must
+// hold the lock.
+func (b *Bar) renderLine() string {
+	if b.config.Row > 0 {
+		return b.positioned(b.render())
 	}
 
-	b.clearLine()
-	fmt.Fprintf(b.config.Writer, "\r")
+	line := "\r" + b.render()
+	if b.canErase {
+		line += eraseLine
+	}
+
+	// Redraw the message line above, then come back down to the bar
+	if b.config.TwoLine {
+		message := truncateVisible(b.message, b.lineWidth())
+		line = cursorUp + "\r" + message + eraseLine + "\n" + line
+	}
+	return line
 }
 
-// SetProgress updates the progress (0.0 to 1.0)
-func (b *Bar) SetProgress(progress float64) {
-	b.lock.RLock()
-	if !b.started || b.stopped {
-		b.lock.RUnlock()
-		return
+// positioned returns content drawn at the fixed position set with
+// WithPosition, clamped to the terminal size, with the cursor saved and
+// restored around it. Any part of the previous line beyond the new content
+// is blanked out with spaces rather than erased, so text to the right of the
+// bar is left alone. The caller must hold the lock.
+func (b *Bar) positioned(content string) string {
+	row, col := b.config.Row, max(1, b.config.Col)
+	if b.termHeight > 0 {
+		row = min(row, b.termHeight)
+	}
+	if b.termWidth > 0 {
+		col = min(col, b.termWidth)
 	}
-	b.lock.RUnlock()
 
-	// Constrain progress to valid range
-	progress = math.Max(0.0, math.Min(1.0, progress))
+	width := visibleWidth(content)
+	padding := strings.Repeat(" ", max(0, b.drawnWidth-width))
+	b.drawnWidth = width
 
-	b.lock.Lock()
-	b.lastProgress = progress
-	b.lock.Unlock()
+	return fmt.Sprintf("%s\033[%d;%dH%s%s%s", saveCursor, row, col, content, padding, restoreCursor)
+}
+
+// lineWidth returns the width of the terminal, or of the line the bar is
+// sized for if that is unknown. The caller must hold the lock.
+func (b *Bar) lineWidth() int {
+	if b.termWidth > 0 {
+		return b.termWidth
+	}
+	return b.totalWidth + b.reservedWidth() + b.margin + 2
+}
+
+// DefaultRenderer renders the standard bar layout. Custom renderers set with
+// WithRenderer can call it to decorate or wrap the default output.
+func DefaultRenderer(state BarState) string {
+	if state.Config.Compact {
+		return renderCompact(state)
+	}
+
+	activity := ""
+	if frames := state.Config.ActivityFrames; len(frames) > 0 {
+		activity = frames[state.Frame%len(frames)] + " "
+	}
+
+	if state.Indeterminate() {
+		return activity + renderIndeterminate(state)
+	}
+
+	// Give the message a fixed column taken from the bar, so the bar doesn't
+	// jump around as messages of different lengths come and go
+	message := ""
+	if state.Message != "" {
+		column := state.Width / 3
+		message = padVisible(truncateVisible(state.Message, column), column) + " "
+		state.Width -= column + 1
+	}
 
-	// Calculate filled and empty portions
-	filledCount := int(float64(b.totalWidth) * progress)
-	emptyCount := b.totalWidth - filledCount
+	// Calculate filled, secondary and empty portions
+	filledCount := int(float64(state.Width) * state.Progress)
+	secondaryEnd := max(int(float64(state.Width)*state.Secondary), filledCount)
+	secondaryCount := secondaryEnd - filledCount
+	emptyCount := state.Width - secondaryEnd
+
+	percentage := int(state.Progress * 100)
+	percentLeft := state.Config.ShowPercent && state.Config.PercentPosition == PercentLeft
 
 	// Build progress bar string
 	var bar strings.Builder
-	bar.WriteString("\r")
+	bar.WriteString(activity)
+	bar.WriteString(message)
+
+	// Lead with the percentage, padded so the bar's left edge doesn't move
+	if percentLeft {
+		bar.WriteString(fmt.Sprintf("%3d%% ", percentage))
+	}
 
 	// Write filled portion
-	for i := 0; i < filledCount; i++ {
-		bar.WriteString(b.config.FilledChar)
+	filled := strings.Repeat(state.Config.FilledChar, filledCount)
+	if state.Config.FillColor != nil && filled != "" {
+		filled = state.Config.FillColor(state.Progress, filled)
 	}
+	bar.WriteString(filled)
+
+	// Write secondary portion
+	bar.WriteString(strings.Repeat(state.Config.SecondaryChar, secondaryCount))
 
 	// Write empty portion
 	for i := 0; i < emptyCount; i++ {
-		bar.WriteString(b.config.EmptyChar)
+		bar.WriteString(state.Config.EmptyChar)
 	}
 
 	// Add percentage if enabled
-	if b.config.ShowPercent {
-		percentage := int(progress * 100)
+	if state.Config.ShowPercent && !percentLeft {
 		bar.WriteString(fmt.Sprintf(" %3d%%", percentage))
 	}
 
-	// Add ETA if enabled
-	if b.config.ShowETA {
-		eta := b.calculateETA(progress)
+	// Add the item count if counting
+	if state.TotalCount > 0 {
+		digits := len(strconv.Itoa(state.TotalCount))
+		bar.WriteString(fmt.Sprintf(" (%*d/%d)", digits, state.Count, state.TotalCount))
+	}
+
+	if state.Config.Sparkline {
+		bar.WriteString(" " + renderSparkline(state.Samples))
+	}
+
+	// Add elapsed time and ETA if enabled
+	switch {
+	case state.Config.ShowETA && state.Config.ShowElapsed:
+		eta := calculateETA(state.Progress, state.Elapsed)
+		bar.WriteString(fmt.Sprintf(" %s / ETA %s", formatDuration(state.Elapsed), eta))
+	case state.Config.ShowETA:
+		eta := calculateETA(state.Progress, state.Elapsed)
 		bar.WriteString(fmt.Sprintf(" ETA: %s", eta))
+	case state.Config.ShowElapsed:
+		bar.WriteString(" " + formatDuration(state.Elapsed))
+	}
+
+	return bar.String()
+}
+
+// renderCompact builds a line with just a spinner glyph and the percentage,
+// or the bytes transferred when the total is unknown
+func renderCompact(state BarState) string {
+	frames := state.Config.ActivityFrames
+	if len(frames) == 0 {
+		frames = defaultActivityFrames
+	}
+	glyph := frames[state.Frame%len(frames)]
+
+	switch {
+	case state.TotalBytes < 0:
+		return fmt.Sprintf("%s %s", glyph, formatBytes(state.Bytes))
+	case state.Indeterminate():
+		return glyph
+	default:
+		return fmt.Sprintf("%s %3d%%", glyph, int(state.Progress*100))
+	}
+}
+
+// renderIndeterminate builds a line with a bouncing pulse followed by the
+// bytes transferred and the transfer rate
+func renderIndeterminate(state BarState) string {
+	pulseWidth := max(state.Width/4, 1)
+	travel := state.Width - pulseWidth
+
+	// Bounce the pulse back and forth across the bar
+	pos := 0
+	if travel > 0 {
+		pos = state.Frame % (2 * travel)
+		if pos > travel {
+			pos = 2*travel - pos
+		}
+	}
+
+	var bar strings.Builder
+	bar.WriteString(strings.Repeat(state.Config.EmptyChar, pos))
+	bar.WriteString(strings.Repeat(state.Config.FilledChar, pulseWidth))
+	bar.WriteString(strings.Repeat(state.Config.EmptyChar, travel-pos))
+
+	if state.TotalBytes < 0 {
+		bar.WriteString(fmt.Sprintf(" %9s  %s/s", formatBytes(state.Bytes), formatBytes(int64(state.Rate))))
+	}
+
+	return bar.String()
+}
+
+// formatBytes formats a byte count using decimal units (e.g. "12.4 MB")
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
 
-	fmt.Fprint(b.config.Writer, bar.String())
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
 }
 
 // calculateETA estimates time remaining based on current progress
-func (b *Bar) calculateETA(progress float64) string {
+func calculateETA(progress float64, elapsed time.Duration) string {
 	if progress <= 0 {
 		return "--:--"
 	}
 
-	elapsed := time.Since(b.startTime)
-	totalEstimated := time.Duration(float64(elapsed) / progress)
-	remaining := totalEstimated - elapsed
+	// Work in floating point so tiny progress values can't overflow
+	remaining := float64(elapsed)/progress - float64(elapsed)
+	remaining = math.Max(0, math.Min(remaining, float64(maxDuration)))
 
-	if remaining < 0 {
-		remaining = 0
-	}
+	return formatDuration(time.Duration(remaining))
+}
+
+// maxDuration is the longest duration formatDuration can represent
+const maxDuration = 99*time.Hour + 59*time.Minute + 59*time.Second
 
-	minutes := int(remaining.Minutes())
-	seconds := int(remaining.Seconds()) % 60
+// formatDuration formats d as MM:SS, or as HH:MM:SS once it exceeds an hour,
+// capping at 99:59:59 so the field never grows wider than reserved
+func formatDuration(d time.Duration) string {
+	d = min(d, maxDuration)
 
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
 	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
 
@@ -337,19 +1518,291 @@ func (b *Bar) Run(fn func(setProgress func(float64))) {
 
 	go func() {
 		defer wg.Done()
+		defer func() {
+			// Restore the terminal before the panic ends the program
+			if r := recover(); r != nil {
+				b.Stop()
+				panic(r)
+			}
+		}()
 		fn(b.SetProgress)
 	}()
 
 	wg.Wait()
 }
 
+// RunParallel runs tasks with at most n running at once, advancing bar as
+// each one completes. It starts the bar, stops it when the tasks are done,
+// and returns the first error. After an error no new tasks are started, but
+// tasks already running are allowed to finish. n <= 0 runs every task at
+// once.
+func RunParallel(n int, tasks []func() error, bar *Bar) error {
+	if n <= 0 {
+		n = len(tasks)
+	}
+
+	bar.Start()
+	defer bar.Stop()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		failed   = make(chan struct{})
+		slots    = make(chan struct{}, max(n, 1))
+		done     = 0
+	)
+
+schedule:
+	for _, task := range tasks {
+		select {
+		case slots <- struct{}{}:
+		case <-failed:
+			break schedule
+		}
+
+		// Both cases may have been ready, so check again before starting
+		select {
+		case <-failed:
+			<-slots
+			break schedule
+		default:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := task(); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					close(failed)
+				})
+				return
+			}
+
+			bar.update(func() float64 {
+				done++
+				return float64(done) / float64(len(tasks))
+			})
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// SetSecondaryProgress updates the secondary progress (0.0 to 1.0), such as
+// the amount buffered or downloaded ahead of what has been processed. It is
+// drawn with the secondary character beyond the filled portion.
+func (b *Bar) SetSecondaryProgress(progress float64) {
+	progress = clampProgress(progress)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.secondary = progress
+	if !b.started || b.stopped || b.config.CIMode {
+		return
+	}
+
+	b.redraw()
+}
+
+// redraw writes the current line, counting it in Stats. It is written while
+// holding the lock so concurrent updates can't interleave partial lines, and
+// identical redraws are skipped to avoid flicker when the same value is
+// reported repeatedly. The caller must hold the lock.
+func (b *Bar) redraw() {
+	line := b.renderLine()
+	if line == b.lastLine {
+		b.dropped.Add(1)
+		return
+	}
+
+	fmt.Fprint(b.config.Writer, b.diff(line))
+	b.lastLine = line
+	b.redraws.Add(1)
+}
+
 // Increment increases progress by a delta amount
 func (b *Bar) Increment(delta float64) {
-	b.lock.RLock()
-	current := b.lastProgress
-	b.lock.RUnlock()
+	b.update(func() float64 { return b.lastProgress + delta })
+}
+
+// SetBytes sets the number of bytes transferred so far. When the total is
+// known, progress is updated to match.
+func (b *Bar) SetBytes(n int64) {
+	b.update(func() float64 {
+		b.bytes = n
+		return b.bytesProgress()
+	})
+}
+
+// AddBytes adds to the number of bytes transferred so far
+func (b *Bar) AddBytes(n int64) {
+	b.update(func() float64 {
+		b.bytes += n
+		return b.bytesProgress()
+	})
+}
+
+// bytesProgress returns the progress for the bytes transferred, or the
+// current progress if the total isn't known. The caller must hold the lock.
+func (b *Bar) bytesProgress() float64 {
+	if b.config.TotalBytes <= 0 {
+		return b.lastProgress
+	}
+	return float64(b.bytes) / float64(b.config.TotalBytes)
+}
+
+// SetTotal sets the number of bytes expected once it becomes known, such
+// as when a download's size arrives after it has started. Progress is
+// recomputed from the bytes already counted, and a bar started with an
+// unknown total switches from the pulse to a percentage. n <= 0 switches
+// back to an unknown total.
+func (b *Bar) SetTotal(n int64) {
+	b.update(func() float64 {
+		if n <= 0 {
+			b.config.TotalBytes = -1
+		} else {
+			b.config.TotalBytes = n
+			b.config.Indeterminate = false
+		}
+		b.calculateWidth() // The annotations after the bar have changed
+
+		// Animate the pulse only while the total is unknown
+		running := b.started && !b.stopped && !b.config.CIMode
+		switch {
+		case b.doneCh != nil && !b.isAnimated():
+			close(b.doneCh)
+			b.doneCh = nil
+		case b.doneCh == nil && b.isAnimated() && running:
+			b.doneCh = make(chan struct{})
+			go b.animate(b.doneCh)
+		}
+
+		if n <= 0 {
+			return 0
+		}
+		return float64(b.bytes) / float64(n)
+	})
+}
+
+// SetMessage sets the text shown before the bar, such as the name of the
+// item being processed. Long messages are truncated to fit the line.
+func (b *Bar) SetMessage(message string) {
+	b.update(func() float64 {
+		b.message = message
+		return b.lastProgress // Redraw with the new message
+	})
+}
+
+// SetCount sets the number of items completed, updating progress from the
+// total set with WithCount
+func (b *Bar) SetCount(n int) {
+	b.update(func() float64 {
+		b.count = max(0, n)
+		return b.countProgress()
+	})
+}
+
+// IncrementCount marks one more item as completed
+func (b *Bar) IncrementCount() {
+	b.update(func() float64 {
+		b.count++
+		return b.countProgress()
+	})
+}
+
+// countProgress returns the progress implied by the item count. The caller
+// must hold the lock.
+func (b *Bar) countProgress() float64 {
+	if b.config.TotalCount <= 0 {
+		return b.lastProgress
+	}
+	return float64(b.count) / float64(b.config.TotalCount)
+}
+
+// truncateVisible shortens s to at most width visible characters, ending
+// with "…" when cut. ANSI escape sequences are copied through without
+// counting toward the width, and a reset is appended if any were cut short.
+func truncateVisible(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if visibleWidth(s) <= width {
+		return s
+	}
+
+	var out strings.Builder
+	visible := 0
+	styled := false
+	for i := 0; i < len(s); {
+		if s[i] == '\033' {
+			end := escapeEnd(s, i)
+			out.WriteString(s[i:end])
+			styled = true
+			i = end
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible+color.RuneWidth(r) > width-1 {
+			break
+		}
+		out.WriteRune(r)
+		visible += color.RuneWidth(r)
+		i += size
+	}
+
+	out.WriteString("…")
+	if styled {
+		out.WriteString("\033[0m")
+	}
+	return out.String()
+}
+
+// padVisible pads s with spaces to width visible columns
+func padVisible(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-visibleWidth(s)))
+}
+
+// visibleWidth returns the number of terminal columns s occupies, ignoring
+// ANSI escape sequences
+func visibleWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\033' {
+			i = escapeEnd(s, i)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += color.RuneWidth(r)
+		i += size
+	}
+	return width
+}
+
+// escapeEnd returns the index just past the CSI escape sequence starting at
+// s[start]
+func escapeEnd(s string, start int) int {
+	i := start + 1
+	if i < len(s) && s[i] == '[' {
+		i++
+		for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+			i++
+		}
+	}
+	return min(i+1, len(s))
+}
 
-	b.SetProgress(current + delta)
+// Writer returns the writer the bar renders to
+func (b *Bar) Writer() io.Writer {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.config.Writer
 }
 
 // GetProgress returns the current progress value
@@ -373,48 +1826,255 @@ func (b *Bar) IsStopped() bool {
 	return b.stopped
 }
 
+// Pause stops the clock used for the elapsed time, ETA and rate, so time
+// spent waiting (e.g. on user input) doesn't skew the estimates. The last
+// line stays on screen until Resume is called.
+func (b *Bar) Pause() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.started || b.stopped || b.paused {
+		return
+	}
+
+	b.paused = true
+	b.pauseStart = time.Now()
+}
+
+// Resume restarts the clock stopped by Pause
+func (b *Bar) Resume() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.paused {
+		return
+	}
+
+	// Shift the start forward so the paused time isn't counted
+	paused := time.Since(b.pauseStart)
+	b.startTime = b.startTime.Add(paused)
+	if !b.rateTime.IsZero() {
+		b.rateTime = b.rateTime.Add(paused)
+	}
+	if !b.sampleTime.IsZero() {
+		b.sampleTime = b.sampleTime.Add(paused)
+	}
+	b.paused = false
+}
+
+// IsPaused returns whether the bar is paused
+func (b *Bar) IsPaused() bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.paused
+}
+
+// elapsed returns the running time, excluding time spent paused. The caller
+// must hold the lock.
+func (b *Bar) elapsed() time.Duration {
+	if b.paused {
+		return b.pauseStart.Sub(b.startTime)
+	}
+	return time.Since(b.startTime)
+}
+
 // Reset resets the progress bar to initial state
 func (b *Bar) Reset() {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
 	b.lastProgress = 0
+	b.secondary = 0
+	b.bytes = 0
+	b.count = 0
+	b.message = ""
+	b.lastLogged = 0
+	b.lastCILine = 0
+	b.completed = false
+	b.samples = nil
+	b.sampleTime = time.Time{}
+	b.rateTime = time.Time{}
+	b.rateBytes = 0
+	b.instantRate = 0
+	b.smoothedRate = 0
+	b.timedOut = false
 	b.started = false
 	b.stopped = false
+	b.paused = false
 	b.startTime = time.Time{}
+
+	b.redraws.Store(0)
+	b.dropped.Store(0)
+	b.resizes.Store(0)
 }
 
-// MultiBar manages multiple progress bars
+// Stats returns the bar's rendering counters since it was created or last
+// reset
+func (b *Bar) Stats() Stats {
+	return Stats{
+		Redraws: b.redraws.Load(),
+		Dropped: b.dropped.Load(),
+		Resizes: b.resizes.Load(),
+	}
+}
+
+// MultiBar manages multiple progress bars, each drawn on its own line of a
+// region reserved below the cursor
 type MultiBar struct {
-	bars map[string]*LabeledBar
-	lock sync.RWMutex
+	bars   map[string]*LabeledBar
+	writer io.Writer
+	lock   sync.RWMutex
+
+	// Guarded by drawLock, which serializes all output to the region
+	order    []*LabeledBar
+	lines    int
+	drawLock sync.Mutex
 }
 
 // LabeledBar represents a progress bar with a label
 type LabeledBar struct {
 	*Bar
-	label string
-	line  int
+	label   string
+	line    int
+	content string
+}
+
+// lineWriter positions a bar's output on its line of a MultiBar region
+type lineWriter struct {
+	mb  *MultiBar
+	bar *LabeledBar
+}
+
+// Write stores the bar's latest line and redraws it in place
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mb.drawLock.Lock()
+	defer w.mb.drawLock.Unlock()
+
+	w.bar.content = lineContent(p)
+	if w.bar.line < w.mb.lines {
+		drawAbove(w.mb.writer, w.mb.lines-w.bar.line, w.mb.renderLine(w.bar))
+	}
+	return len(p), nil
+}
+
+// lineContent returns the line a bar's write leaves on screen. Bars drawn
+// by a MultiBar or Group always write whole lines (see Bar.wholeLines), so
+// only the final carriage-return segment matters.
+func lineContent(p []byte) string {
+	content := strings.TrimSuffix(string(p), "\n")
+	if i := strings.LastIndex(content, "\r"); i >= 0 {
+		content = content[i+1:]
+	}
+	return strings.TrimSuffix(content, eraseLine)
+}
+
+// drawAbove redraws the line up rows above the cursor with line, which
+// should end by erasing the rest of the line, and returns the cursor to
+// where it was
+func drawAbove(w io.Writer, up int, line string) error {
+	_, err := fmt.Fprintf(w, "\033[%dA\r%s\033[%dB\r", up, line, up)
+	return err
 }
 
 // NewMultiBar creates a new multi-bar manager
 func NewMultiBar() *MultiBar {
+	return NewMultiBarWithWriter(os.Stdout)
+}
+
+// NewMultiBarWithWriter creates a new multi-bar manager drawing to writer
+func NewMultiBarWithWriter(writer io.Writer) *MultiBar {
 	return &MultiBar{
-		bars: make(map[string]*LabeledBar),
+		bars:   make(map[string]*LabeledBar),
+		writer: writer,
 	}
 }
 
-// Add adds a labeled progress bar
+// Add adds a labeled progress bar, reserving a new line for it below any
+// existing bars. Bars added while others are running are placed correctly.
 func (mb *MultiBar) Add(name, label string, config BarConfig, opts ...Option) {
 	mb.lock.Lock()
 	defer mb.lock.Unlock()
 
-	bar := NewBarWithConfig(config, opts...)
-	mb.bars[name] = &LabeledBar{
-		Bar:   bar,
-		label: label,
-		line:  len(mb.bars),
+	lb := &LabeledBar{label: label}
+	opts = append(opts, WithWriter(&lineWriter{mb: mb, bar: lb}))
+	lb.Bar = NewBarWithConfig(config, opts...)
+	lb.Bar.wholeLines = true
+
+	// Leave room for the label when sizing to the terminal
+	lb.Bar.margin = utf8.RuneCountInString(label) + 1
+	lb.Bar.calculateWidth()
+
+	mb.drawLock.Lock()
+	defer mb.drawLock.Unlock()
+
+	// Replace an existing bar of the same name in place
+	if existing, exists := mb.bars[name]; exists {
+		for i, b := range mb.order {
+			if b == existing {
+				mb.order[i] = lb
+			}
+		}
+	} else {
+		mb.order = append(mb.order, lb)
+	}
+	mb.bars[name] = lb
+
+	mb.reflow()
+}
+
+// Remove stops and removes a progress bar, closing up its line
+func (mb *MultiBar) Remove(name string) {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	lb, exists := mb.bars[name]
+	if !exists {
+		return
+	}
+	delete(mb.bars, name)
+	lb.Bar.Stop()
+
+	mb.drawLock.Lock()
+	defer mb.drawLock.Unlock()
+
+	for i, b := range mb.order {
+		if b == lb {
+			mb.order = append(mb.order[:i], mb.order[i+1:]...)
+			break
+		}
+	}
+
+	mb.reflow()
+}
+
+// reflow redraws every bar at its position in the region, growing or
+// shrinking the region to fit. The caller must hold drawLock.
+func (mb *MultiBar) reflow() {
+	var out strings.Builder
+
+	// Move to the top of the existing region
+	if mb.lines > 0 {
+		fmt.Fprintf(&out, "\033[%dA", mb.lines)
+	}
+
+	for i, lb := range mb.order {
+		lb.line = i
+		out.WriteString("\r" + mb.renderLine(lb) + "\n")
+	}
+
+	// Clear lines left over from a larger region, then return below the bars
+	if extra := mb.lines - len(mb.order); extra > 0 {
+		out.WriteString(strings.Repeat("\r"+eraseLine+"\n", extra))
+		fmt.Fprintf(&out, "\033[%dA", extra)
 	}
+
+	mb.lines = len(mb.order)
+	fmt.Fprint(mb.writer, out.String())
+}
+
+// renderLine returns the full line for a bar, including its label
+func (mb *MultiBar) renderLine(lb *LabeledBar) string {
+	return lb.label + " " + lb.content + eraseLine
 }
 
 // Start starts a specific progress bar
@@ -423,7 +2083,6 @@ func (mb *MultiBar) Start(name string) {
 	defer mb.lock.RUnlock()
 
 	if bar, exists := mb.bars[name]; exists {
-		fmt.Printf("%s:\n", bar.label)
 		bar.Start()
 	}
 }
@@ -435,7 +2094,7 @@ func (mb *MultiBar) Stop(name string) {
 
 	if bar, exists := mb.bars[name]; exists {
 		bar.Stop()
-		fmt.Printf("%s: Complete!\n", bar.label)
+		fmt.Fprint(bar.config.Writer, "\rComplete!")
 	}
 }
 
@@ -459,6 +2118,64 @@ func (mb *MultiBar) StopAll() {
 	}
 }
 
+// Group stacks independent bars on consecutive lines below the cursor.
+// Each bar added to a group owns one line and redraws only that line, so
+// bars can be started, updated and stopped separately without a central
+// map of names. Bars in a group shouldn't use WithPosition or WithTwoLine.
+type Group struct {
+	writer io.Writer
+	lines  int // Lines reserved so far; the cursor sits below the last one
+	lock   sync.Mutex
+}
+
+// groupLine positions a bar's output on its line of a Group
+type groupLine struct {
+	group *Group
+	index int
+}
+
+// Write redraws the bar's latest line in place
+func (l *groupLine) Write(p []byte) (int, error) {
+	l.group.lock.Lock()
+	defer l.group.lock.Unlock()
+
+	err := drawAbove(l.group.writer, l.group.lines-l.index, lineContent(p)+eraseLine)
+	return len(p), err
+}
+
+// NewGroup creates an empty group drawing to stdout
+func NewGroup() *Group {
+	return NewGroupWithWriter(os.Stdout)
+}
+
+// NewGroupWithWriter creates an empty group drawing to writer
+func NewGroupWithWriter(writer io.Writer) *Group {
+	return &Group{writer: writer}
+}
+
+// Add creates a bar drawn on a new line below the group's existing bars.
+// The line is reserved immediately; the bar is used like any other and
+// must be started with Start.
+func (g *Group) Add(config BarConfig, opts ...Option) *Bar {
+	g.lock.Lock()
+	line := &groupLine{group: g, index: g.lines}
+	g.lines++
+	fmt.Fprint(g.writer, "\n")
+	g.lock.Unlock()
+
+	opts = append(opts, WithWriter(line))
+	bar := NewBarWithConfig(config, opts...)
+	bar.wholeLines = true
+	return bar
+}
+
+// Len returns the number of lines reserved by the group
+func (g *Group) Len() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.lines
+}
+
 // Convenience functions
 
 // Quick creates and runs a simple progress bar
@@ -482,19 +2199,16 @@ func WithStyle(style BarConfig) *Bar {
 	return NewBarWithStyle(style)
 }
 
-// DownloadProgress simulates a download progress bar
+// DownloadProgress simulates a download progress bar. A totalBytes of zero
+// or less is treated as an unknown size.
 func DownloadProgress(totalBytes int64, fn func(downloaded func(int64))) {
-	bar := NewBarWithConfig(StyleDefault, WithETA(true))
+	if totalBytes <= 0 {
+		totalBytes = -1
+	}
+
+	bar := NewBarWithConfig(StyleDefault, WithETA(true), WithTotalBytes(totalBytes))
 	bar.Start()
 	defer bar.Stop()
 
-	downloadedBytes := int64(0)
-	downloadFunc := func(bytes int64) {
-		downloadedBytes += bytes
-		progress := float64(downloadedBytes) / float64(totalBytes)
-		bar.SetProgress(progress)
-	}
-
-	fn(downloadFunc)
+	fn(bar.AddBytes)
 }
-
@@ -0,0 +1,441 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{"zero", 0, "00:00"},
+		{"under an hour", 59*time.Minute + 59*time.Second, "59:59"},
+		{"exactly an hour", time.Hour, "01:00:00"},
+		{"multi-hour", 12*time.Hour + 34*time.Minute + 56*time.Second, "12:34:56"},
+		{"capped", 250 * time.Hour, "99:59:59"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDuration(tt.d); got != tt.want {
+				t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+// screen replays output on a simulated terminal, handling carriage
+// returns, line feeds, cursor up/down and erase-in-line, and returns the
+// visible lines
+func screen(out string) []string {
+	var lines [][]rune
+	row, col := 0, 0
+	for i := 0; i < len(out); {
+		for len(lines) <= row {
+			lines = append(lines, nil)
+		}
+
+		switch {
+		case out[i] == '\r':
+			col = 0
+			i++
+		case out[i] == '\n':
+			row++
+			i++
+		case out[i] == '\033' && i+1 < len(out) && out[i+1] == '[':
+			j := i + 2
+			for j < len(out) && (out[j] < 0x40 || out[j] > 0x7e) {
+				j++
+			}
+			if j == len(out) {
+				return nil
+			}
+			n, err := strconv.Atoi(out[i+2 : j])
+			if err != nil {
+				n = 1
+			}
+			switch out[j] {
+			case 'A':
+				row = max(0, row-n)
+			case 'B':
+				row += n
+			case 'K':
+				if col < len(lines[row]) {
+					lines[row] = lines[row][:col]
+				}
+			}
+			i = j + 1
+		default:
+			r, size := utf8.DecodeRuneInString(out[i:])
+			for len(lines[row]) < col {
+				lines[row] = append(lines[row], ' ')
+			}
+			if col < len(lines[row]) {
+				lines[row][col] = r
+			} else {
+				lines[row] = append(lines[row], r)
+			}
+			col++
+			i += size
+		}
+	}
+
+	visible := make([]string, len(lines))
+	for i, line := range lines {
+		visible[i] = strings.TrimRight(string(line), " ")
+	}
+	return visible
+}
+
+func TestMultiBarAddAfterStart(t *testing.T) {
+	var out bytes.Buffer
+	mb := NewMultiBarWithWriter(&out)
+
+	mb.Add("first", "first", StyleDefault, WithWidth(10), WithCIMode(false))
+	mb.Start("first")
+	mb.SetProgress("first", 0.5)
+
+	mb.Add("second", "second", StyleDefault, WithWidth(10), WithCIMode(false))
+	mb.Start("second")
+	mb.SetProgress("second", 1)
+	mb.SetProgress("first", 0.6)
+
+	lines := screen(out.String())
+	if len(lines) < 2 {
+		t.Fatalf("screen has %d lines, want 2: %q", len(lines), lines)
+	}
+	if !strings.HasPrefix(lines[0], "first") || !strings.HasSuffix(lines[0], "60%") {
+		t.Errorf("line 0 = %q, want the first bar at 60%%", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "second") || !strings.HasSuffix(lines[1], "100%") {
+		t.Errorf("line 1 = %q, want the second bar at 100%%", lines[1])
+	}
+}
+
+// overlapWriter records writes that start before the previous one finished
+// or that aren't whole lines
+type overlapWriter struct {
+	writing  atomic.Bool
+	overlaps atomic.Int64
+	partial  atomic.Int64
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if w.writing.Swap(true) {
+		w.overlaps.Add(1)
+	}
+	if len(p) > 0 && p[0] != '\r' && p[0] != '\n' {
+		w.partial.Add(1)
+	}
+	time.Sleep(time.Microsecond)
+	w.writing.Store(false)
+	return len(p), nil
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	w := &overlapWriter{}
+	bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(w), WithWidth(20))
+	bar.Start()
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				bar.SetProgress(float64(i) / 200)
+				if i%10 == 0 {
+					bar.SetMessage(fmt.Sprintf("worker %d", g))
+				}
+				if g == 0 && i == 150 {
+					bar.Stop()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	bar.Stop()
+
+	if n := w.overlaps.Load(); n > 0 {
+		t.Errorf("%d writes overlapped", n)
+	}
+	if n := w.partial.Load(); n > 0 {
+		t.Errorf("%d writes weren't whole lines", n)
+	}
+}
+
+func TestTerminalWidths(t *testing.T) {
+	tests := []struct {
+		name      string
+		termWidth int
+		wantBar   int // Expected bar width, or 0 to derive it from the line
+	}{
+		{"narrower than the annotations", 8, 10},
+		{"narrow", 19, 10},
+		{"wide", 250, 0},
+		{"very wide", 1000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sizeFunc := func(fd int) (int, int, error) { return tt.termWidth, 24, nil }
+			bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(&out), WithSizeFunc(sizeFunc),
+				WithETA(true), WithElapsed(true))
+			bar.Start()
+			bar.SetMessage("a message longer than the narrow terminals")
+			bar.SetProgress(0.5)
+			bar.lock.Lock()
+			line, barWidth := bar.lastLine, bar.totalWidth
+			bar.lock.Unlock()
+			bar.Stop()
+
+			if !strings.Contains(line, "50%") {
+				t.Errorf("line %q doesn't show the percentage", line)
+			}
+			if tt.wantBar > 0 {
+				if barWidth != tt.wantBar {
+					t.Errorf("bar width = %d, want the minimum %d", barWidth, tt.wantBar)
+				}
+				return
+			}
+			if got := visibleWidth(line) - 1; got > tt.termWidth { // -1 for the "\r"
+				t.Errorf("line is %d columns, wider than the %d column terminal", got, tt.termWidth)
+			}
+			if barWidth < tt.termWidth/2 {
+				t.Errorf("bar width = %d, want it to fill most of the %d column terminal", barWidth, tt.termWidth)
+			}
+		})
+	}
+}
+
+func TestNonFiniteProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress float64
+	}{
+		{"NaN", math.NaN()},
+		{"+Inf", math.Inf(1)},
+		{"-Inf", math.Inf(-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(&out), WithWidth(10))
+			bar.Start()
+			bar.SetProgress(0.5)
+			bar.SetProgress(tt.progress)
+			bar.lock.Lock()
+			line := bar.lastLine
+			bar.lock.Unlock()
+			bar.Stop()
+
+			if strings.Contains(out.String(), "NaN") || strings.Contains(out.String(), "Inf") {
+				t.Errorf("output contains a non-finite value: %q", out.String())
+			}
+			if !strings.Contains(line, " 0%") && !strings.Contains(line, "100%") {
+				t.Errorf("line = %q, want 0%% or 100%%", line)
+			}
+			if got := bar.GetProgress(); math.IsNaN(got) || got < 0 || got > 1 {
+				t.Errorf("GetProgress() = %v, want a value in [0, 1]", got)
+			}
+		})
+	}
+}
+
+func TestSecondaryProgressCountsRedraws(t *testing.T) {
+	var out bytes.Buffer
+	bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(&out), WithWidth(10))
+	bar.Start()
+	bar.SetProgress(0.2)
+	before := bar.Stats().Redraws
+
+	bar.SetSecondaryProgress(0.5)
+	bar.SetSecondaryProgress(0.5)
+	bar.Stop()
+
+	if got := bar.Stats().Redraws - before; got != 1 {
+		t.Errorf("SetSecondaryProgress counted %d redraws, want 1", got)
+	}
+}
+
+func TestDiffLineWideCharacters(t *testing.T) {
+	prev := "\r日本 [##  ] 40%" + eraseLine
+	next := "\r日本 [##  ] 45%" + eraseLine
+
+	got := diffLine(prev, next)
+	want := "\r\033[13C5%" + eraseLine // 日本 take two columns each
+	if got != want {
+		t.Errorf("diffLine(%q, %q) = %q, want %q", prev, next, got, want)
+	}
+}
+
+func TestVisibleWidthWideCharacters(t *testing.T) {
+	if got := visibleWidth("\033[1m日本\033[0m ab"); got != 7 {
+		t.Errorf("visibleWidth = %d, want 7", got)
+	}
+
+	got := truncateVisible("日本語テキスト", 6)
+	if want := "日本…"; got != want {
+		t.Errorf("truncateVisible = %q, want %q", got, want)
+	}
+	if w := visibleWidth(got); w > 6 {
+		t.Errorf("truncated width = %d, want at most 6", w)
+	}
+}
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"none", nil, false},
+		{"CI", map[string]string{"CI": "true"}, true},
+		{"GitHub Actions", map[string]string{"GITHUB_ACTIONS": "true"}, true},
+		{"Jenkins", map[string]string{"JENKINS_URL": "http://ci.example.com"}, true},
+		{"CI=false", map[string]string{"CI": "false"}, false},
+		{"CI=0", map[string]string{"CI": "0"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range ciEnvVars {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			if got := detectCI(); got != tt.want {
+				t.Errorf("detectCI() = %v, want %v", got, tt.want)
+			}
+
+			bar := NewBarWithConfig(StyleDefault, WithWriter(io.Discard))
+			if bar.config.CIMode != tt.want {
+				t.Errorf("CIMode = %v, want %v", bar.config.CIMode, tt.want)
+			}
+			if bar := NewBarWithConfig(StyleDefault, WithCIMode(false)); bar.config.CIMode {
+				t.Error("WithCIMode(false) didn't override detection")
+			}
+		})
+	}
+}
+
+func TestConcurrentAddBytes(t *testing.T) {
+	bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(io.Discard), WithTotalBytes(16*500))
+	bar.Start()
+	defer bar.Stop()
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 500 {
+				bar.AddBytes(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := bar.GetProgress(); got != 1 {
+		t.Errorf("GetProgress() = %v after every byte was added, want 1", got)
+	}
+}
+
+func TestSetMessageKeepsProgress(t *testing.T) {
+	bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(io.Discard))
+	bar.Start()
+	defer bar.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			bar.SetMessage(fmt.Sprintf("item %d", i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range 1001 {
+			bar.SetProgress(float64(i) / 1000)
+		}
+	}()
+	wg.Wait()
+
+	if got := bar.GetProgress(); got != 1 {
+		t.Errorf("GetProgress() = %v, want the last value set, 1", got)
+	}
+}
+
+func TestRunParallelStopsAfterFailure(t *testing.T) {
+	for range 100 {
+		var started atomic.Int64
+		tasks := []func() error{
+			func() error { return errors.New("failed") },
+		}
+		for range 5 {
+			tasks = append(tasks, func() error {
+				started.Add(1)
+				return nil
+			})
+		}
+
+		bar := NewBarWithConfig(StyleDefault, WithCIMode(false), WithWriter(io.Discard))
+		if err := RunParallel(1, tasks, bar); err == nil {
+			t.Fatal("RunParallel() returned nil, want the task's error")
+		}
+		if n := started.Load(); n > 0 {
+			t.Fatalf("%d tasks started after the first failed", n)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	for _, diff := range []bool{false, true} {
+		t.Run(fmt.Sprintf("diff rendering %v", diff), func(t *testing.T) {
+			var out bytes.Buffer
+			g := NewGroupWithWriter(&out)
+
+			first := g.Add(StyleDefault, WithWidth(10), WithCIMode(false), WithDiffRendering(diff))
+			first.Start()
+			first.SetProgress(0.3)
+
+			second := g.Add(StyleDefault, WithWidth(10), WithCIMode(false), WithDiffRendering(diff))
+			second.Start()
+			for i := 0; i <= 10; i++ {
+				second.SetProgress(float64(i) / 10)
+				first.SetProgress(0.3 + float64(i)/100)
+			}
+
+			if got := g.Len(); got != 2 {
+				t.Errorf("Len() = %d, want 2", got)
+			}
+
+			lines := screen(out.String())
+			want := []string{"####        40%", "########## 100%"}
+			for i, w := range want {
+				if i >= len(lines) || lines[i] != w {
+					t.Errorf("screen = %q, want lines %q", lines, want)
+					break
+				}
+			}
+		})
+	}
+}
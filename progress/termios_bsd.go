@@ -0,0 +1,11 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package progress
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests for reading and writing terminal attributes
+const (
+	ioctlReadTermios  = unix.TIOCGETA
+	ioctlWriteTermios = unix.TIOCSETA
+)
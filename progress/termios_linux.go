@@ -0,0 +1,11 @@
+//go:build linux
+
+package progress
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests for reading and writing terminal attributes
+const (
+	ioctlReadTermios  = unix.TCGETS
+	ioctlWriteTermios = unix.TCSETS
+)
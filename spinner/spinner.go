@@ -2,11 +2,23 @@
 package spinner
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/dreamsofcode-io/termui/color"
+	"golang.org/x/term"
+)
+
+// Lifecycle errors returned by StartE and StopE
+var (
+	ErrAlreadyRunning = errors.New("spinner: already running")
+	ErrNotRunning     = errors.New("spinner: not running")
 )
 
 // Frames represents a sequence of animation frames
@@ -30,16 +42,73 @@ var (
 	FramesProgress = []rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
 )
 
+// FrameSet is a sequence of animation frames along with their display
+// width. Each frame is padded to Width columns when drawn, so frames of
+// different widths (e.g. mixing emoji with narrow glyphs) don't shift the
+// text after the spinner.
+type FrameSet struct {
+	Frames []string
+	Width  int // Widest frame in terminal columns (0 = compute from Frames)
+}
+
+// NewFrameSet creates a frame set, computing its width from the frames
+func NewFrameSet(frames ...string) FrameSet {
+	set := FrameSet{Frames: frames}
+	for _, f := range frames {
+		set.Width = max(set.Width, color.DisplayWidth(f))
+	}
+	return set
+}
+
+// Predefined frame sets
+var (
+	FrameSetLines    = FrameSet{Frames: []string{"|", "/", "-", "\\"}, Width: 1}
+	FrameSetDots     = FrameSet{Frames: []string{"⣾", "⣽", "⣻", "⢿", "⡿", "⣟", "⣯", "⣷"}, Width: 1}
+	FrameSetBounce   = FrameSet{Frames: []string{".", "o", "O", "o"}, Width: 1}
+	FrameSetArrows   = FrameSet{Frames: []string{"↖", "↗", "↘", "↙"}, Width: 1}
+	FrameSetProgress = FrameSet{Frames: []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}, Width: 1}
+	FrameSetMoon     = FrameSet{Frames: []string{"🌑", "🌒", "🌓", "🌔", "🌕", "🌖", "🌗", "🌘"}, Width: 2}
+	FrameSetClock    = FrameSet{Frames: []string{"🕛", "🕐", "🕑", "🕒", "🕓", "🕔", "🕕", "🕖", "🕗", "🕘", "🕙", "🕚"}, Width: 2}
+)
+
 // Spinner represents a terminal loading spinner
 type Spinner struct {
-	frames        Frames
+	frameSet      FrameSet
 	frameDuration time.Duration
 	writer        io.Writer
 	prefix        string
 	suffix        string
+	frameIndex    int
+	dots          bool
+	level         color.LogLevel
+	leveled       bool
+	tee           io.Writer
+	maxDuration   time.Duration
+	onTimeout     func()
+	startDelay    time.Duration
+	status        string
+	hasStatus     bool
+	failOnPanic   bool
+	frameColor    func(string) string
+	prefixColor   func(string) string
+	saveCursor    bool
+	hideCursor    bool
+	hideCursorSet bool
+	smoothFPS     int
+	messageFunc   func() string
+	bellOnStop    bool
+	frameLeft     string // Inserted between the prefix and the frame
+	frameRight    string // Inserted between the frame and the suffix
+	alignSuffix   bool   // Whether to push the suffix to the right edge
+	onWriteError  func(error)
+	writeErr      error  // Write error that stopped the last run
+	lastLine      string // Most recently drawn line, for redrawing after Println
+	startTime     time.Time
+	stopTime      time.Time
 	doneCh        chan struct{}
 	finishedCh    chan struct{}
 	lock          sync.Mutex
+	writeLock     sync.Mutex // Serializes terminal writes between the render loop and Println
 	running       bool
 }
 
@@ -53,10 +122,27 @@ func WithFrameDuration(duration time.Duration) Option {
 	}
 }
 
-// WithFrames sets the animation frames to use
+// WithFrameSet sets the animation frames along with their display width. An
+// empty set falls back to FrameSetLines. A zero Width is computed from the
+// frames.
+func WithFrameSet(set FrameSet) Option {
+	return func(s *Spinner) {
+		if set.Width == 0 {
+			set = NewFrameSet(set.Frames...)
+		}
+		s.frameSet = set
+	}
+}
+
+// WithFrames sets the animation frames to use. An empty set falls back to
+// FramesLines.
 func WithFrames(frames Frames) Option {
 	return func(s *Spinner) {
-		s.frames = frames
+		set := make([]string, len(frames))
+		for i, r := range frames {
+			set[i] = string(r)
+		}
+		s.frameSet = NewFrameSet(set...)
 	}
 }
 
@@ -67,6 +153,14 @@ func WithWriter(writer io.Writer) Option {
 	}
 }
 
+// WithTee copies every byte written by the spinner to an additional writer,
+// such as a log capturing the raw escape sequences for debugging
+func WithTee(tee io.Writer) Option {
+	return func(s *Spinner) {
+		s.tee = tee
+	}
+}
+
 // WithPrefix sets text to display before the spinner
 func WithPrefix(prefix string) Option {
 	return func(s *Spinner) {
@@ -81,10 +175,151 @@ func WithSuffix(suffix string) Option {
 	}
 }
 
+// WithDots replaces the spinning frame with trailing dots that cycle from
+// none to three after the prefix (e.g. "Loading", "Loading.", "Loading...")
+func WithDots(enabled bool) Option {
+	return func(s *Spinner) {
+		s.dots = enabled
+	}
+}
+
+// WithLevel colors the prefix and final symbol according to the log level,
+// using the color package's current theme
+func WithLevel(level color.LogLevel) Option {
+	return func(s *Spinner) {
+		s.level = level
+		s.leveled = true
+	}
+}
+
+// WithFrameColor sets a color function applied to the animated frame only
+func WithFrameColor(colorFunc func(string) string) Option {
+	return func(s *Spinner) {
+		s.frameColor = colorFunc
+	}
+}
+
+// WithPrefixColor sets a color function applied to the prefix and suffix
+// text, taking precedence over the color chosen by WithLevel
+func WithPrefixColor(colorFunc func(string) string) Option {
+	return func(s *Spinner) {
+		s.prefixColor = colorFunc
+	}
+}
+
+// WithSaveCursor draws each frame between save and restore cursor
+// sequences, leaving the cursor at the start of the spinner's line. Output
+// written elsewhere then starts at the left edge and pushes the spinner
+// down a line; use Println to also clear the spinner's text first.
+func WithSaveCursor(enabled bool) Option {
+	return func(s *Spinner) {
+		s.saveCursor = enabled
+	}
+}
+
+// WithHideCursor hides the terminal cursor while the spinner is drawn,
+// restoring it when the spinner stops. It defaults to on when the writer is
+// a terminal.
+func WithHideCursor(enabled bool) Option {
+	return func(s *Spinner) {
+		s.hideCursor = enabled
+		s.hideCursorSet = true
+	}
+}
+
+// WithSmooth redraws the spinner at the given frame rate (capped at
+// maxSmoothFPS) while still advancing frames at the frame duration, so
+// prefix, suffix and status changes appear without waiting for the next
+// frame. Redraws that wouldn't change the line are skipped to keep CPU use
+// low. A rate no faster than the frame rate has no effect.
+func WithSmooth(fps int) Option {
+	return func(s *Spinner) {
+		s.smoothFPS = min(fps, maxSmoothFPS)
+	}
+}
+
+// WithMessageFunc sets a function called on every frame to produce the
+// prefix, e.g. to show a live queue depth or retry count. It is called
+// without the spinner's lock held, so it may safely call spinner methods.
+func WithMessageFunc(fn func() string) Option {
+	return func(s *Spinner) {
+		s.messageFunc = fn
+	}
+}
+
+// WithStartDelay holds off drawing until the spinner has been running for
+// the given duration, so fast operations finish without a flash of output.
+// If Stop is called before the delay elapses nothing is ever drawn.
+func WithStartDelay(delay time.Duration) Option {
+	return func(s *Spinner) {
+		s.startDelay = delay
+	}
+}
+
+// WithFailOnPanic makes Run finalize the spinner with a failure mark if the
+// function panics, before re-panicking, so the terminal is left tidy
+func WithFailOnPanic(enabled bool) Option {
+	return func(s *Spinner) {
+		s.failOnPanic = enabled
+	}
+}
+
+// WithMaxDuration stops the spinner automatically once it has been running
+// for the given duration, guarding against operations that never return
+func WithMaxDuration(duration time.Duration) Option {
+	return func(s *Spinner) {
+		s.maxDuration = duration
+	}
+}
+
+// WithOnTimeout sets a function to call when the spinner is stopped by
+// WithMaxDuration. It is not called when the spinner is stopped normally.
+func WithOnTimeout(fn func()) Option {
+	return func(s *Spinner) {
+		s.onTimeout = fn
+	}
+}
+
+// WithBellOnStop rings the terminal bell when the spinner stops, including
+// via StopWithSuccess and StopWithFailure, as an audible cue that a long
+// operation has finished (off by default)
+func WithBellOnStop(enabled bool) Option {
+	return func(s *Spinner) {
+		s.bellOnStop = enabled
+	}
+}
+
+// WithOnWriteError sets a function to call when writing a frame fails, for
+// example because the terminal went away. The spinner stops on the first
+// failed write rather than retrying every frame; see also Err.
+func WithOnWriteError(fn func(error)) Option {
+	return func(s *Spinner) {
+		s.onWriteError = fn
+	}
+}
+
+// WithFrameSpacing sets text to insert either side of the frame, e.g. " "
+// and " " to keep the frame clear of the prefix and suffix
+func WithFrameSpacing(left, right string) Option {
+	return func(s *Spinner) {
+		s.frameLeft = left
+		s.frameRight = right
+	}
+}
+
+// WithRightAlignedSuffix pushes the suffix to the right edge of the
+// terminal, e.g. to keep an elapsed time in a fixed place. It has no effect
+// when the writer isn't a terminal or the line is too long to pad.
+func WithRightAlignedSuffix(enabled bool) Option {
+	return func(s *Spinner) {
+		s.alignSuffix = enabled
+	}
+}
+
 // New creates a new spinner with the given options
 func New(opts ...Option) *Spinner {
 	s := &Spinner{
-		frames:        FramesLines,
+		frameSet:      FrameSetLines,
 		frameDuration: 100 * time.Millisecond,
 		writer:        os.Stdout,
 		prefix:        "",
@@ -95,64 +330,462 @@ func New(opts ...Option) *Spinner {
 		opt(s)
 	}
 
+	// An empty frame set would leave nothing to render
+	if len(s.frameSet.Frames) == 0 {
+		s.frameSet = FrameSetLines
+	}
+
+	if !s.hideCursorSet {
+		s.hideCursor = isTerminal(s.writer)
+	}
+
 	return s
 }
 
-// Start begins the spinner animation
+// Start begins the spinner animation. Starting a running spinner does
+// nothing; use StartE or TryStart to detect it.
 func (s *Spinner) Start() {
+	s.TryStart()
+}
+
+// StartE begins the spinner animation, returning ErrAlreadyRunning if it is
+// already running
+func (s *Spinner) StartE() error {
+	if !s.TryStart() {
+		return ErrAlreadyRunning
+	}
+	return nil
+}
+
+// TryStart begins the spinner animation, reporting whether it was started.
+// It returns false if the spinner was already running.
+func (s *Spinner) TryStart() bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	// Prevent multiple starts
 	if s.running {
-		return
+		return false
 	}
 
 	doneCh := make(chan struct{})
 	finishedCh := make(chan struct{})
 
-	go func() {
-		defer close(finishedCh)
-		defer s.clearLine()
+	go s.animate(doneCh, finishedCh)
 
-		ticker := time.NewTicker(s.frameDuration)
-		defer ticker.Stop()
+	s.doneCh = doneCh
+	s.finishedCh = finishedCh
+	s.running = true
+	s.lastLine = ""
+	s.startTime = time.Now()
+	s.stopTime = time.Time{}
+	s.writeErr = nil
+	return true
+}
 
-		frameIndex := 0
+// animate renders frames until doneCh is closed or the max duration elapses
+func (s *Spinner) animate(doneCh, finishedCh chan struct{}) {
+	s.lock.Lock()
+	frameDuration := s.frameDuration
+	tickDuration := frameDuration
+	smooth := false
+	// A smooth rate slower than the frame rate would slow the animation
+	if s.smoothFPS > 0 && time.Second/time.Duration(s.smoothFPS) < frameDuration {
+		tickDuration = time.Second / time.Duration(s.smoothFPS)
+		smooth = true
+	}
+	startDelay := s.startDelay
+	maxDuration := s.maxDuration
+	onTimeout := s.onTimeout
+	hideCursor := s.hideCursor
+	messageFunc := s.messageFunc
+	bellOnStop := s.bellOnStop
+	onWriteError := s.onWriteError
+	s.lock.Unlock()
 
-		for {
-			select {
-			case <-ticker.C:
-				frame := s.frames[frameIndex%len(s.frames)]
-				fmt.Fprintf(s.writer, "\r%s%c%s", s.prefix, frame, s.suffix)
-				frameIndex++
+	timedOut := false
+	drawn := false
+	var writeErr error
 
-			case <-doneCh:
-				return
+	defer close(finishedCh)
+	defer func() {
+		if timedOut && onTimeout != nil {
+			onTimeout()
+		}
+		if writeErr != nil && onWriteError != nil {
+			onWriteError(writeErr)
+		}
+	}()
+	defer func() {
+		if !drawn && !bellOnStop {
+			return // Nothing to clear if the start delay never elapsed
+		}
+
+		s.writeLock.Lock()
+		defer s.writeLock.Unlock()
+
+		s.lock.Lock()
+		w := s.output()
+		s.lastLine = ""
+		s.lock.Unlock()
+
+		// Best effort, and outside the lock so a blocked writer can't hold
+		// up the rest of the spinner
+		if drawn {
+			s.clearLine(w)
+			if hideCursor {
+				fmt.Fprint(w, showCursorSeq)
 			}
 		}
+		if bellOnStop {
+			fmt.Fprint(w, "\a")
+		}
 	}()
 
-	s.doneCh = doneCh
-	s.finishedCh = finishedCh
-	s.running = true
+	var ticker *time.Ticker
+	var ticks, delay <-chan time.Time
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	if startDelay > 0 {
+		timer := time.NewTimer(startDelay)
+		defer timer.Stop()
+		delay = timer.C
+	} else {
+		ticker = time.NewTicker(tickDuration)
+		ticks = ticker.C
+	}
+	animStart := time.Now()
+	steps := 0
+
+	var deadline <-chan time.Time
+	if maxDuration > 0 {
+		timer := time.NewTimer(maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		select {
+		case <-delay:
+			// Still running after the delay, so begin animating
+			ticker = time.NewTicker(tickDuration)
+			ticks = ticker.C
+			animStart = time.Now()
+
+		case <-ticks:
+			var message string
+			if messageFunc != nil {
+				message = messageFunc()
+			}
+
+			// Hold the write lock from choosing the writer until the frame
+			// is written, so SetWriter can't swap it in between
+			s.writeLock.Lock()
+			s.lock.Lock()
+			if messageFunc != nil {
+				s.prefix = message
+			}
+			w := s.output()
+			if smooth {
+				// Advance by however many frame durations have passed
+				elapsed := int(time.Since(animStart) / frameDuration)
+				s.frameIndex += elapsed - steps
+				steps = elapsed
+			}
+			line := s.renderLine()
+			unchanged := line == s.lastLine
+			s.lastLine = line
+			if !smooth {
+				s.frameIndex++
+			}
+			s.lock.Unlock()
+
+			if smooth && unchanged && drawn {
+				s.writeLock.Unlock()
+				continue
+			}
+
+			if hideCursor && !drawn {
+				line = hideCursorSeq + line
+			}
+
+			_, err := fmt.Fprint(w, line)
+			s.writeLock.Unlock()
+			if err != nil {
+				// The writer is broken (e.g. a closed pipe), so stop rather
+				// than failing on every tick
+				s.lock.Lock()
+				if s.release(doneCh) {
+					s.writeErr = err
+					writeErr = err
+				}
+				s.lock.Unlock()
+				drawn = false // Nothing can be cleared
+				return
+			}
+			drawn = true
+
+		case <-deadline:
+			s.lock.Lock()
+			timedOut = s.release(doneCh)
+			s.lock.Unlock()
+			return
+
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// release marks the run identified by doneCh as stopped from within the
+// render loop, reporting false if Stop has already claimed it. The caller
+// must hold the lock.
+func (s *Spinner) release(doneCh chan struct{}) bool {
+	if s.doneCh != doneCh {
+		return false
+	}
+
+	s.doneCh = nil
+	s.finishedCh = nil
+	s.running = false
+	s.stopTime = time.Now()
+	return true
+}
+
+// Err returns the write error that stopped the spinner's last run, or nil
+// if it wasn't stopped by an error. It is reset by Start.
+func (s *Spinner) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.writeErr
 }
 
 // Stop stops the spinner animation and cleans up
 func (s *Spinner) Stop() {
+	// Wait without holding the lock, as the render loop acquires it
+	if finishedCh := s.stop(); finishedCh != nil {
+		<-finishedCh
+	}
+}
+
+// StopE stops the spinner like Stop, returning ErrNotRunning if it wasn't
+// running
+func (s *Spinner) StopE() error {
+	finishedCh := s.stop()
+	if finishedCh == nil {
+		return ErrNotRunning
+	}
+	<-finishedCh
+	return nil
+}
+
+// StopWithTimeout stops the spinner like Stop, but gives up waiting for the
+// render goroutine to finish after the timeout, for example when the writer
+// is blocked on a slow pipe. The spinner is marked as stopped either way.
+func (s *Spinner) StopWithTimeout(timeout time.Duration) error {
+	finishedCh := s.stop()
+	if finishedCh == nil {
+		return nil
+	}
+
+	select {
+	case <-finishedCh:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("spinner did not stop within %v", timeout)
+	}
+}
+
+// stop marks the spinner as stopped and signals the render goroutine,
+// returning a channel closed once it has finished, or nil if the spinner
+// wasn't running
+func (s *Spinner) stop() chan struct{} {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	if !s.running || s.doneCh == nil {
-		return
+		return nil
 	}
 
+	finishedCh := s.finishedCh
 	close(s.doneCh)
-	<-s.finishedCh
 
 	s.doneCh = nil
 	s.finishedCh = nil
 	s.running = false
+	s.stopTime = time.Now()
+
+	return finishedCh
+}
+
+// StopWithSymbol stops the spinner and replaces it with a final line showing
+// symbol followed by message. When a level is set the symbol is colored to
+// match it. An empty message reuses the prefix.
+func (s *Spinner) StopWithSymbol(symbol, message string) {
+	s.Stop()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.finish(s.colorize(symbol), message)
+}
+
+// StopWithSuccess stops the spinner and leaves a success mark and message
+func (s *Spinner) StopWithSuccess(message string) {
+	s.Stop()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.finish(s.mark(color.Success, "✓"), message)
+}
+
+// StopWithFailure stops the spinner and leaves a failure mark and message
+func (s *Spinner) StopWithFailure(message string) {
+	s.Stop()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.finish(s.mark(color.Error, "✗"), message)
+}
+
+// finish writes the final line for a stopped spinner. The caller must hold
+// the lock.
+func (s *Spinner) finish(symbol, message string) {
+	if message == "" {
+		message = strings.TrimSpace(s.prefix)
+	}
+	fmt.Fprintf(s.output(), "%s %s\n", symbol, message)
+}
+
+// RenderFrame returns exactly what the spinner would write to draw the
+// given frame index with its current prefix, suffix and options, without
+// starting it. It is intended for golden tests of the animation.
+func (s *Spinner) RenderFrame(index int) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.renderFrame(index)
+}
+
+// renderLine builds the line for the current frame. The caller must hold the
+// lock.
+func (s *Spinner) renderLine() string {
+	return s.renderFrame(s.frameIndex)
+}
+
+// renderFrame builds the line for a frame, coloring the prefix, frame and
+// suffix independently. The line ends with an erase so no visible-width
+// bookkeeping is needed to clear longer previous output. The caller must
+// hold the lock.
+func (s *Spinner) renderFrame(index int) string {
+	prefix, frame, suffix := s.colorize(s.prefix), s.frame(max(0, index)), s.currentSuffix()
+
+	if s.prefixColor != nil {
+		prefix = s.paint(s.prefixColor, s.prefix)
+		suffix = s.paint(s.prefixColor, suffix)
+	}
+	if s.frameColor != nil {
+		frame = s.paint(s.frameColor, frame)
+	}
+
+	left := prefix + s.frameLeft + frame + s.frameRight
+	if s.alignSuffix && suffix != "" {
+		left += strings.Repeat(" ", s.alignPadding(left, suffix))
+	}
+
+	line := left + suffix + eraseLine
+	if s.saveCursor {
+		return "\r" + saveCursor + line + restoreCursor
+	}
+	return "\r" + line
+}
+
+// alignPadding returns the spaces needed between left and suffix for the
+// suffix to end at the right edge of the terminal, or 0 if the terminal
+// width is unknown or the line doesn't fit. The last column is left empty
+// so the terminal doesn't wrap. The caller must hold the lock.
+func (s *Spinner) alignPadding(left, suffix string) int {
+	f, ok := s.writer.(*os.File)
+	if !ok {
+		return 0
+	}
+	width, _, err := getSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+
+	used := color.DisplayWidth(left) + color.DisplayWidth(suffix)
+	return max(0, width-1-used)
+}
+
+// Println prints a line above the spinner: the spinner's line is cleared,
+// the text written in its place, and the spinner redrawn below it. Arguments
+// are formatted as with fmt.Println. Output goes to the spinner's writer.
+func (s *Spinner) Println(a ...any) {
+	s.Bypass(func(w io.Writer) {
+		fmt.Fprintln(w, a...)
+	})
+}
+
+// Bypass clears the spinner's line and calls fn with the spinner's writer,
+// so arbitrary output can be printed above the spinner, then redraws the
+// spinner. The output should end with a newline. The spinner doesn't draw
+// while fn runs, and fn must not call Println or Bypass.
+func (s *Spinner) Bypass(fn func(w io.Writer)) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	s.lock.Lock()
+	w := s.output()
+	line := s.lastLine
+	s.lock.Unlock()
+
+	if line != "" {
+		s.clearLine(w)
+	}
+	fn(w)
+	if line != "" {
+		fmt.Fprint(w, line)
+	}
+}
+
+// paint applies colorFunc to text, unless the text is empty or color is
+// disabled for the spinner's writer (NO_COLOR is set or the writer isn't a
+// terminal). All spinner coloring goes through here. The caller must hold
+// the lock.
+func (s *Spinner) paint(colorFunc func(string) string, text string) string {
+	if text == "" || !color.EnabledFor(s.writer) {
+		return text
+	}
+	return colorFunc(text)
+}
+
+// mark colors a final symbol with the level's theme color if a level is
+// set, and with colorFunc otherwise. The caller must hold the lock.
+func (s *Spinner) mark(colorFunc func(string) string, symbol string) string {
+	if s.leveled {
+		return s.colorize(symbol)
+	}
+	return s.paint(colorFunc, symbol)
+}
+
+// colorize applies the level's theme color to text, if a level is set. The
+// caller must hold the lock.
+func (s *Spinner) colorize(text string) string {
+	if !s.leveled {
+		return text
+	}
+
+	switch s.level {
+	case color.ERROR:
+		return s.paint(color.Error, text)
+	case color.WARN:
+		return s.paint(color.Warning, text)
+	default:
+		return s.paint(color.Info, text)
+	}
 }
 
 // IsRunning returns whether the spinner is currently running
@@ -162,6 +795,41 @@ func (s *Spinner) IsRunning() bool {
 	return s.running
 }
 
+// Elapsed returns how long the spinner has been running, or how long it ran
+// for once stopped. The timer restarts on each Start.
+func (s *Spinner) Elapsed() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch {
+	case s.startTime.IsZero():
+		return 0
+	case s.running:
+		return time.Since(s.startTime)
+	default:
+		return s.stopTime.Sub(s.startTime)
+	}
+}
+
+// FrameIndex returns the index of the next frame to be rendered
+func (s *Spinner) FrameIndex() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.frameIndex
+}
+
+// SetFrameIndex sets the index of the next frame to be rendered, allowing
+// multiple spinners to be offset from (or synchronised with) each other
+func (s *Spinner) SetFrameIndex(i int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if i < 0 {
+		i = 0
+	}
+	s.frameIndex = i
+}
+
 // SetPrefix updates the prefix text (can be called while running)
 func (s *Spinner) SetPrefix(prefix string) {
 	s.lock.Lock()
@@ -176,15 +844,135 @@ func (s *Spinner) SetSuffix(suffix string) {
 	s.suffix = suffix
 }
 
-// clearLine clears the current line in the terminal
-func (s *Spinner) clearLine() {
-	// Calculate the total width to clear
-	maxWidth := len(s.prefix) + len(s.suffix) + 1 // +1 for spinner character
-	clearStr := make([]byte, maxWidth)
-	for i := range clearStr {
-		clearStr[i] = ' '
+// SetFrames replaces the animation frames (can be called while running,
+// taking effect on the next frame). An empty set falls back to FramesLines.
+func (s *Spinner) SetFrames(frames Frames) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	WithFrames(frames)(s)
+	if len(s.frameSet.Frames) == 0 {
+		s.frameSet = FrameSetLines
+	}
+}
+
+// SetWriter changes the output writer (can be called while running). A
+// running spinner's line is cleared from the old writer and drawn on the
+// new one from the next frame; no frame is written to the old writer after
+// SetWriter returns.
+func (s *Spinner) SetWriter(writer io.Writer) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	s.lock.Lock()
+	old := s.output()
+	drawn := s.running && s.lastLine != ""
+	hideCursor := s.hideCursor
+	s.writer = writer
+	s.lastLine = ""
+	w := s.output()
+	s.lock.Unlock()
+
+	if !drawn {
+		return
+	}
+
+	// Move the hidden cursor along with the line
+	s.clearLine(old)
+	if hideCursor {
+		fmt.Fprint(old, showCursorSeq)
+		fmt.Fprint(w, hideCursorSeq)
+	}
+}
+
+// SetColor changes the function used to color the frame (can be called
+// while running, taking effect on the next frame). nil removes the color.
+func (s *Spinner) SetColor(colorFunc func(string) string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.frameColor = colorFunc
+}
+
+const (
+	// maxDots is the longest run of trailing dots rendered in dots mode
+	maxDots = 3
+
+	// eraseLine clears from the cursor to the end of the line, so a shorter
+	// line never leaves characters from a longer one behind
+	eraseLine = "\033[K"
+
+	// saveCursor and restoreCursor bracket frames drawn with WithSaveCursor
+	saveCursor    = "\033[s"
+	restoreCursor = "\033[u"
+
+	// maxSmoothFPS caps the redraw rate set with WithSmooth
+	maxSmoothFPS = 60
+
+	// hideCursorSeq and showCursorSeq toggle cursor visibility
+	hideCursorSeq = "\033[?25l"
+	showCursorSeq = "\033[?25h"
+)
+
+// frame returns the animation frame for the given index. The caller must
+// hold the lock.
+func (s *Spinner) frame(index int) string {
+	if s.dots {
+		n := index % (maxDots + 1)
+		// Pad to the longest state so shorter states overwrite longer ones
+		return strings.Repeat(".", n) + strings.Repeat(" ", maxDots-n)
+	}
+	frame := s.frameSet.Frames[index%len(s.frameSet.Frames)]
+	return frame + strings.Repeat(" ", max(0, s.frameSet.Width-color.DisplayWidth(frame)))
+}
+
+// getSize reports the terminal size, and can be replaced to simulate one
+var getSize = term.GetSize
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// output returns the writer to render to, including any tee. The caller
+// must hold the lock.
+func (s *Spinner) output() io.Writer {
+	if s.tee == nil {
+		return s.writer
+	}
+	return io.MultiWriter(s.writer, s.tee)
+}
+
+// Statusf temporarily shows a status message in place of the suffix, for
+// reporting sub-steps without creating a new spinner. Each call replaces the
+// previous status; ClearStatus restores the suffix.
+func (s *Spinner) Statusf(format string, args ...any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = fmt.Sprintf(format, args...)
+	s.hasStatus = true
+}
+
+// ClearStatus removes the status set by Statusf, restoring the suffix
+func (s *Spinner) ClearStatus() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = ""
+	s.hasStatus = false
+}
+
+// currentSuffix returns the status if one is set, otherwise the suffix. The
+// caller must hold the lock.
+func (s *Spinner) currentSuffix() string {
+	if s.hasStatus {
+		return s.status
 	}
-	fmt.Fprintf(s.writer, "\r%s\r", clearStr)
+	return s.suffix
+}
+
+// clearLine clears the current line in the terminal
+func (s *Spinner) clearLine(w io.Writer) {
+	fmt.Fprint(w, "\r"+eraseLine)
 }
 
 // Restart stops and then starts the spinner (useful for changing options)
@@ -197,9 +985,45 @@ func (s *Spinner) Restart() {
 func (s *Spinner) Run(fn func()) {
 	s.Start()
 	defer s.Stop()
+
+	s.lock.Lock()
+	failOnPanic := s.failOnPanic
+	s.lock.Unlock()
+
+	if failOnPanic {
+		defer func() {
+			if r := recover(); r != nil {
+				s.StopWithFailure("")
+				panic(r)
+			}
+		}()
+	}
+
 	fn()
 }
 
+// RunE runs a function while displaying the spinner and returns its error,
+// finishing with a success mark, or a failure mark followed by the error
+func (s *Spinner) RunE(fn func() error) error {
+	var err error
+	s.Run(func() { err = fn() })
+
+	if err == nil {
+		s.StopWithSuccess("")
+		return nil
+	}
+
+	s.lock.Lock()
+	message := err.Error()
+	if prefix := strings.TrimSpace(s.prefix); prefix != "" {
+		message = prefix + ": " + message
+	}
+	s.lock.Unlock()
+
+	s.StopWithFailure(message)
+	return err
+}
+
 // RunWithTimeout runs a function with a spinner and timeout
 func (s *Spinner) RunWithTimeout(fn func() error, timeout time.Duration) error {
 	s.Start()
@@ -227,10 +1051,21 @@ type MultiSpinner struct {
 // LabeledSpinner represents a spinner with a label
 type LabeledSpinner struct {
 	*Spinner
-	label string
-	line  int
+	label  string
+	line   int
+	result result
+	err    error
 }
 
+// result records how a spinner's task ended
+type result int
+
+const (
+	resultPending result = iota
+	resultSuccess
+	resultFailure
+)
+
 // NewMultiSpinner creates a new multi-spinner manager
 func NewMultiSpinner() *MultiSpinner {
 	return &MultiSpinner{
@@ -305,6 +1140,97 @@ func (ms *MultiSpinner) UpdateLabel(name, newLabel string) {
 	}
 }
 
+// MarkSuccess records that the task for a spinner succeeded, for FinishAll
+func (ms *MultiSpinner) MarkSuccess(name string) {
+	ms.mark(name, resultSuccess, nil)
+}
+
+// MarkFailure records that the task for a spinner failed with err, for
+// FinishAll
+func (ms *MultiSpinner) MarkFailure(name string, err error) {
+	ms.mark(name, resultFailure, err)
+}
+
+// mark records the result for a spinner
+func (ms *MultiSpinner) mark(name string, r result, err error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if spinner, exists := ms.spinners[name]; exists {
+		spinner.result = r
+		spinner.err = err
+	}
+}
+
+// Summary returns a tally of the recorded results, such as
+// "3 succeeded, 1 failed". Spinners with no result are counted as pending.
+func (ms *MultiSpinner) Summary() string {
+	ms.lock.RLock()
+	defer ms.lock.RUnlock()
+
+	var succeeded, failed, pending int
+	for _, spinner := range ms.spinners {
+		switch spinner.result {
+		case resultSuccess:
+			succeeded++
+		case resultFailure:
+			failed++
+		default:
+			pending++
+		}
+	}
+
+	summary := fmt.Sprintf("%d succeeded, %d failed", succeeded, failed)
+	if pending > 0 {
+		summary += fmt.Sprintf(", %d pending", pending)
+	}
+	return summary
+}
+
+// FinishAll stops every spinner, replacing each with a ✓ or ✗ line for its
+// recorded result (spinners with no result get a "-"), in the order they
+// were added, followed by the Summary line
+func (ms *MultiSpinner) FinishAll() {
+	// Snapshot the spinners and their results, so the lock isn't held
+	// while waiting for each one to stop
+	ms.lock.RLock()
+	spinners := make([]LabeledSpinner, 0, len(ms.spinners))
+	for _, spinner := range ms.spinners {
+		spinners = append(spinners, *spinner)
+	}
+	ms.lock.RUnlock()
+
+	sort.Slice(spinners, func(i, j int) bool {
+		return spinners[i].line < spinners[j].line
+	})
+
+	for _, spinner := range spinners {
+		spinner.Stop()
+	}
+
+	var w io.Writer = os.Stdout
+	for _, spinner := range spinners {
+		spinner.lock.Lock()
+
+		symbol, message := "-", spinner.label
+		switch spinner.result {
+		case resultSuccess:
+			symbol = spinner.paint(color.Success, "✓")
+		case resultFailure:
+			symbol = spinner.paint(color.Error, "✗")
+			if spinner.err != nil {
+				message = fmt.Sprintf("%s: %v", spinner.label, spinner.err)
+			}
+		}
+
+		spinner.finish(symbol, message)
+		w = spinner.output()
+		spinner.lock.Unlock()
+	}
+
+	fmt.Fprintln(w, ms.Summary())
+}
+
 // Convenience functions for common use cases
 
 // WithMessage creates a spinner with a prefix message
@@ -325,3 +1251,69 @@ func Perform(message string, fn func()) {
 	s.Run(fn)
 }
 
+// The package-level Start and Stop operate on a single hidden spinner on
+// stdout. They are intended for simple programs that show one spinner at a
+// time:
+//
+//	spinner.Start("Working")
+//	defer spinner.Stop()
+var (
+	defaultSpinner *Spinner
+	defaultLock    sync.Mutex
+)
+
+// Start shows the default spinner with a message. Calling Start while it is
+// already running just replaces the message.
+func Start(message string) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	if defaultSpinner != nil {
+		defaultSpinner.SetPrefix(message + " ")
+		return
+	}
+
+	defaultSpinner = WithMessage(message)
+	defaultSpinner.Start()
+}
+
+// Stop stops the default spinner, if running
+func Stop() {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	if defaultSpinner == nil {
+		return
+	}
+
+	defaultSpinner.Stop()
+	defaultSpinner = nil
+}
+
+// Println prints a line above the default spinner if one is running, or to
+// stdout otherwise
+func Println(a ...any) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	if defaultSpinner == nil {
+		fmt.Println(a...)
+		return
+	}
+
+	defaultSpinner.Println(a...)
+}
+
+// Bypass calls fn with a writer for printing above the default spinner if
+// one is running, or with stdout otherwise (see Spinner.Bypass)
+func Bypass(fn func(w io.Writer)) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	if defaultSpinner == nil {
+		fn(os.Stdout)
+		return
+	}
+
+	defaultSpinner.Bypass(fn)
+}
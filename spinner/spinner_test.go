@@ -0,0 +1,160 @@
+package spinner
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dreamsofcode-io/termui/color"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the render goroutine
+type syncBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+// screenLine replays output on a single terminal line, handling carriage
+// returns and erase-in-line, and returns what is left visible
+func screenLine(out string) string {
+	var line []rune
+	col := 0
+	for i := 0; i < len(out); {
+		switch {
+		case out[i] == '\r':
+			col = 0
+			i++
+		case out[i] == '\033' && i+1 < len(out) && out[i+1] == '[':
+			j := i + 2
+			for j < len(out) && (out[j] < 0x40 || out[j] > 0x7e) {
+				j++
+			}
+			if j < len(out) && out[j] == 'K' && col < len(line) {
+				line = line[:col]
+			}
+			i = j + 1
+		default:
+			r, size := utf8.DecodeRuneInString(out[i:])
+			if col < len(line) {
+				line[col] = r
+			} else {
+				line = append(line, r)
+			}
+			col++
+			i += size
+		}
+	}
+	return string(line)
+}
+
+// waitFor polls until cond holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the spinner to draw")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShrinkingPrefixLeavesNoResidue(t *testing.T) {
+	out := &syncBuffer{}
+	s := New(WithWriter(out), WithFrameDuration(5*time.Millisecond), WithHideCursor(false))
+
+	long := "a much longer prefix than the next one "
+	s.SetPrefix(long)
+	s.Start()
+	defer s.Stop()
+	waitFor(t, func() bool { return strings.Contains(out.String(), long) })
+
+	s.SetPrefix("short ")
+	waitFor(t, func() bool { return strings.HasPrefix(screenLine(out.String()), "short ") })
+
+	line := screenLine(out.String())
+	if strings.Contains(line, "longer") || len([]rune(line)) > len("short |") {
+		t.Errorf("line after shrinking the prefix = %q, want only the short prefix and a frame", line)
+	}
+}
+
+func TestEmptyFramesFallBack(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func() *Spinner
+	}{
+		{"WithFrames(nil)", func() *Spinner { return New(WithFrames(nil)) }},
+		{"WithFrames(empty)", func() *Spinner { return New(WithFrames(Frames{})) }},
+		{"WithFrameSet(empty)", func() *Spinner { return New(WithFrameSet(FrameSet{})) }},
+		{"SetFrames(empty)", func() *Spinner {
+			s := New()
+			s.SetFrames(Frames{})
+			return s
+		}},
+	}
+
+	want := New().RenderFrame(1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setup()
+			if got := s.RenderFrame(1); got != want {
+				t.Errorf("RenderFrame(1) = %q, want the default frames' %q", got, want)
+			}
+		})
+	}
+}
+
+func TestStopSymbolsUseLevelColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	color.SetColorLevel(color.ColorLevelBasic)
+	defer color.ResetColorLevel()
+
+	// /dev/null is a character device, so colors are enabled for it; the tee
+	// captures what was written
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer devNull.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		stop func(*Spinner)
+		want string
+	}{
+		{"success", nil, func(s *Spinner) { s.StopWithSuccess("done") }, color.Success("✓")},
+		{"failure", nil, func(s *Spinner) { s.StopWithFailure("done") }, color.Error("✗")},
+		{"success with level", []Option{WithLevel(color.WARN)}, func(s *Spinner) { s.StopWithSuccess("done") }, color.Warning("✓")},
+		{"failure with level", []Option{WithLevel(color.INFO)}, func(s *Spinner) { s.StopWithFailure("done") }, color.Info("✗")},
+		{"symbol with level", []Option{WithLevel(color.ERROR)}, func(s *Spinner) { s.StopWithSymbol("!", "done") }, color.Error("!")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out syncBuffer
+			opts := append([]Option{WithWriter(devNull), WithTee(&out), WithHideCursor(false)}, tt.opts...)
+			tt.stop(New(opts...))
+
+			if want := tt.want + " done\n"; !strings.HasSuffix(out.String(), want) {
+				t.Errorf("output = %q, want it to end with %q", out.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package spinner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dreamsofcode-io/termui/color"
+	"github.com/dreamsofcode-io/termui/progress"
+)
+
+// Step is a single task shown as a spinner while its progress is unknown,
+// switching to a progress bar once progress is reported, and finishing with
+// a ✓ or ✗ line
+type Step struct {
+	label   string
+	spinner *Spinner
+	bar     *progress.Bar
+	done    bool
+	lock    sync.Mutex
+}
+
+// NewStep starts a step showing a spinner labelled with label. Options
+// configure the spinner; the progress bar writes to the same writer.
+func NewStep(label string, opts ...Option) *Step {
+	opts = append([]Option{WithPrefix(label + " ")}, opts...)
+
+	st := &Step{
+		label:   label,
+		spinner: New(opts...),
+	}
+	st.spinner.Start()
+	return st
+}
+
+// SetProgress reports progress (0.0 to 1.0), replacing the spinner with a
+// progress bar on the first call
+func (st *Step) SetProgress(p float64) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if st.done {
+		return
+	}
+
+	if st.bar == nil {
+		st.spinner.Stop()
+
+		st.bar = progress.NewBarWithConfig(progress.StyleDefault,
+			progress.WithWriter(st.spinner.writer),
+			progress.WithPercent(true),
+		)
+		st.bar.Start()
+		st.bar.SetMessage(st.label)
+	}
+
+	st.bar.SetProgress(p)
+}
+
+// Done finishes the step with a success mark
+func (st *Step) Done() {
+	st.finish(color.Success, "✓", st.label)
+}
+
+// Fail finishes the step with a failure mark, followed by err if not nil
+func (st *Step) Fail(err error) {
+	message := st.label
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", st.label, err)
+	}
+	st.finish(color.Error, "✗", message)
+}
+
+// finish stops the spinner or bar and writes the final line, with symbol
+// colored by symbolColor
+func (st *Step) finish(symbolColor func(string) string, symbol, message string) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if st.done {
+		return
+	}
+	st.done = true
+
+	if st.bar != nil {
+		st.bar.Stop()
+	} else {
+		st.spinner.Stop()
+	}
+
+	st.spinner.lock.Lock()
+	defer st.spinner.lock.Unlock()
+	st.spinner.finish(st.spinner.paint(symbolColor, symbol), message)
+}